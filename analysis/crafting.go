@@ -0,0 +1,127 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+const (
+	// AuctionHouseCut is the fraction of the sale price kept by the auction house.
+	AuctionHouseCut = 0.05
+	// DepositRate is the fraction of an item's vendor sell price charged per item to list it for 12
+	// hours.
+	DepositRate = 0.15
+)
+
+// MarketPrices is the current price of a single unit of each item, keyed by item ID.
+type MarketPrices map[int]int
+
+// NewMarketPrices uses the lowest buyout EffectiveUnitPrice of each item as its market price. Bid only
+// auctions are ignored.
+func NewMarketPrices(auctions []wowapiclient.Auction) MarketPrices {
+	prices := make(MarketPrices)
+	for _, a := range auctions {
+		if !a.HasBuyout() {
+			continue
+		}
+		p := a.EffectiveUnitPrice()
+		if cur, ok := prices[a.ItemID]; !ok || p < cur {
+			prices[a.ItemID] = p
+		}
+	}
+	return prices
+}
+
+// CraftingProfit is the estimated profit of crafting a recipe once and selling the result. All prices
+// are in copper.
+type CraftingProfit struct {
+	RealmID         int
+	RecipeID        int
+	RecipeName      string
+	CraftedItemID   int
+	CraftedQuantity float64
+	// ReagentCost is the cost of buying every reagent at market price.
+	ReagentCost int
+	// SalePrice is the market price of everything crafted.
+	SalePrice       int
+	AuctionHouseCut int
+	Deposit         int
+	// Margin is what is left of SalePrice after ReagentCost, AuctionHouseCut and Deposit.
+	Margin int
+	// SellThrough is the sell through of the crafted item, see ItemStats.SellThrough.
+	SellThrough float64
+}
+
+// CraftingProfits estimates the profit of every recipe on a realm, ordered by Margin and then
+// SellThrough. details provides vendor sell prices for deposits and sellThrough is keyed by item ID,
+// both may be missing items. Recipes which do not craft an item, or whose crafted item or reagents
+// have no market price, are left out.
+func CraftingProfits(realmID int, recipes []wowapiclient.Recipe, prices MarketPrices, details map[int]wowapiclient.ItemDetail, sellThrough map[int]float64) []CraftingProfit {
+	var profits []CraftingProfit
+	for _, r := range recipes {
+		itemID := craftedItemID(r)
+		salePrice, ok := prices[itemID]
+		if itemID == 0 || !ok {
+			continue
+		}
+
+		quantity := r.CraftedQuantity
+		if quantity == 0 {
+			quantity = 1
+		}
+
+		reagentCost, ok := reagentCost(r, prices)
+		if !ok {
+			continue
+		}
+
+		p := CraftingProfit{
+			RealmID:         realmID,
+			RecipeID:        r.ID,
+			RecipeName:      r.Name.String(),
+			CraftedItemID:   itemID,
+			CraftedQuantity: quantity,
+			ReagentCost:     reagentCost,
+			SalePrice:       int(math.Round(float64(salePrice) * quantity)),
+			SellThrough:     sellThrough[itemID],
+		}
+		p.AuctionHouseCut = int(math.Round(float64(p.SalePrice) * AuctionHouseCut))
+		if d, ok := details[itemID]; ok {
+			p.Deposit = int(math.Round(float64(d.SellPrice) * DepositRate * quantity))
+		}
+		p.Margin = p.SalePrice - p.ReagentCost - p.AuctionHouseCut - p.Deposit
+
+		profits = append(profits, p)
+	}
+
+	sort.SliceStable(profits, func(i, j int) bool {
+		if profits[i].Margin != profits[j].Margin {
+			return profits[i].Margin > profits[j].Margin
+		}
+		return profits[i].SellThrough > profits[j].SellThrough
+	})
+	return profits
+}
+
+// craftedItemID prefers the faction neutral crafted item, then the alliance one. Faction variants of a
+// crafted item are priced the same on a shared auction house.
+func craftedItemID(r wowapiclient.Recipe) int {
+	if r.CraftedItemID != 0 {
+		return r.CraftedItemID
+	}
+	return r.AllianceCraftedItemID
+}
+
+func reagentCost(r wowapiclient.Recipe, prices MarketPrices) (int, bool) {
+	cost := 0
+	for _, rg := range r.Reagents {
+		p, ok := prices[rg.ItemID]
+		if !ok {
+			return 0, false
+		}
+		cost += p * rg.Quantity
+	}
+	return cost, true
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+func TestCraftingProfits(t *testing.T) {
+	auctions := []wowapiclient.Auction{
+		{ID: 1, ItemID: 10, Quantity: 100, UnitPrice: 20},
+		{ID: 2, ItemID: 10, Quantity: 100, UnitPrice: 25},
+		{ID: 3, ItemID: 11, Quantity: 5, UnitPrice: 100},
+		{ID: 4, ItemID: 20, Quantity: 1, Buyout: 2000},
+		{ID: 5, ItemID: 20, Quantity: 1, Bid: 10},
+		{ID: 6, ItemID: 21, Quantity: 2, Buyout: 200},
+	}
+	recipes := []wowapiclient.Recipe{
+		{
+			ID:            1,
+			CraftedItemID: 20,
+			Reagents:      []wowapiclient.Reagent{{ItemID: 10, Quantity: 10}, {ItemID: 11, Quantity: 2}},
+		},
+		{
+			ID:                    2,
+			AllianceCraftedItemID: 21,
+			HordeCraftedItemID:    22,
+			CraftedQuantity:       2,
+			Reagents:              []wowapiclient.Reagent{{ItemID: 10, Quantity: 1}},
+		},
+		// no market price for reagent 12
+		{ID: 3, CraftedItemID: 20, Reagents: []wowapiclient.Reagent{{ItemID: 12, Quantity: 1}}},
+		// enchants do not craft an item
+		{ID: 4, Reagents: []wowapiclient.Reagent{{ItemID: 10, Quantity: 1}}},
+	}
+	details := map[int]wowapiclient.ItemDetail{
+		20: {SellPrice: 100},
+	}
+
+	profits := CraftingProfits(61, recipes, NewMarketPrices(auctions), details, map[int]float64{21: 0.5})
+	if len(profits) != 2 {
+		t.Fatalf("CraftingProfits() got %v profits, want 2", len(profits))
+	}
+
+	want := CraftingProfit{
+		RealmID:         61,
+		RecipeID:        1,
+		CraftedItemID:   20,
+		CraftedQuantity: 1,
+		ReagentCost:     400,
+		SalePrice:       2000,
+		AuctionHouseCut: 100,
+		Deposit:         15,
+		Margin:          1485,
+	}
+	if profits[0] != want {
+		t.Errorf("CraftingProfits()[0] = %+v, want %+v", profits[0], want)
+	}
+
+	want = CraftingProfit{
+		RealmID:         61,
+		RecipeID:        2,
+		CraftedItemID:   21,
+		CraftedQuantity: 2,
+		ReagentCost:     20,
+		SalePrice:       200,
+		AuctionHouseCut: 10,
+		Margin:          170,
+		SellThrough:     0.5,
+	}
+	if profits[1] != want {
+		t.Errorf("CraftingProfits()[1] = %+v, want %+v", profits[1], want)
+	}
+}
@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+// _minAuctionColumns are the columns every auctions CSV has, later columns were added over time.
+const _minAuctionColumns = 8
+
+// AuctionRecord is the CSV row of a single auction in the format written by fetchauctions.
+func AuctionRecord(a wowapiclient.Auction) []string {
+	return []string{
+		strconv.Itoa(a.ID),
+		strconv.Itoa(a.ItemID),
+		strconv.Itoa(a.Quantity),
+		strconv.Itoa(a.UnitPrice),
+		strconv.Itoa(a.Buyout),
+		strconv.Itoa(a.Bid),
+		string(a.TimeLeft),
+		strconv.Itoa(a.RealmID),
+		strconv.Itoa(a.EffectiveUnitPrice()),
+		string(a.Flavor),
+		string(a.Faction),
+		strconv.Itoa(a.AuctionHouseID),
+	}
+}
+
+// WriteAuctionsCSV writes auctions in the CSV format written by fetchauctions.
+func WriteAuctionsCSV(w io.Writer, auctions []wowapiclient.Auction) error {
+	cw := csv.NewWriter(w)
+	for _, a := range auctions {
+		if err := cw.Write(AuctionRecord(a)); err != nil {
+			return errors.Wrap(err, "failed to write auctions")
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return errors.Wrap(err, "failed to write auctions")
+	}
+	return nil
+}
+
+// ReadAuctionsCSV reads auctions in the CSV format written by fetchauctions.
+func ReadAuctionsCSV(r io.Reader) ([]wowapiclient.Auction, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	var auctions []wowapiclient.Auction
+	for line := 1; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return auctions, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read auctions line %v", line)
+		}
+		if len(row) < _minAuctionColumns {
+			return nil, fmt.Errorf("auctions line %v has %v columns, expected at least %v", line, len(row), _minAuctionColumns)
+		}
+
+		ints := make([]int, 0, 7)
+		for _, i := range []int{0, 1, 2, 3, 4, 5, 7} {
+			v, err := strconv.Atoi(row[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse auctions line %v column %v", line, i+1)
+			}
+			ints = append(ints, v)
+		}
+
+		a := wowapiclient.Auction{
+			ID:        ints[0],
+			ItemID:    ints[1],
+			Quantity:  ints[2],
+			UnitPrice: ints[3],
+			Buyout:    ints[4],
+			Bid:       ints[5],
+			TimeLeft:  wowapiclient.TimeLeft(row[6]),
+			RealmID:   ints[6],
+		}
+		// column 9 is the effective unit price, which is derived
+		if len(row) >= 12 {
+			a.Flavor = wowapiclient.GameFlavor(row[9])
+			a.Faction = wowapiclient.Faction(row[10])
+			if row[11] != "" {
+				ahID, err := strconv.Atoi(row[11])
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to parse auctions line %v column 12", line)
+				}
+				a.AuctionHouseID = ahID
+			}
+		}
+		auctions = append(auctions, a)
+	}
+}
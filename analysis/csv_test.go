@@ -0,0 +1,28 @@
+package analysis
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+func TestAuctionsCSVRoundTrip(t *testing.T) {
+	auctions := []wowapiclient.Auction{
+		{ID: 1, ItemID: 2589, Quantity: 20, UnitPrice: 15, TimeLeft: wowapiclient.TimeLeftLong, RealmID: 61, Flavor: wowapiclient.FlavorRetail},
+		{ID: 2, ItemID: 2592, Quantity: 1, Buyout: 900, Bid: 500, TimeLeft: wowapiclient.TimeLeftShort, RealmID: 4372, Flavor: wowapiclient.FlavorClassic, Faction: wowapiclient.FactionHorde, AuctionHouseID: 6},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAuctionsCSV(&buf, auctions); err != nil {
+		t.Fatalf("WriteAuctionsCSV() error = %v", err)
+	}
+	got, err := ReadAuctionsCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadAuctionsCSV() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, auctions) {
+		t.Errorf("ReadAuctionsCSV() = %+v, want %+v", got, auctions)
+	}
+}
@@ -0,0 +1,162 @@
+// Package analysis derives market information, such as likely sales, from auction house snapshots.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+// Snapshot is every auction listed on a single connected realm at a point in time.
+type Snapshot struct {
+	RealmID  int
+	Time     time.Time
+	Auctions []wowapiclient.Auction
+}
+
+// Disposition is what happened to an auction between two consecutive snapshots.
+type Disposition string
+
+const (
+	// DispositionNew means the auction was listed after the previous snapshot.
+	DispositionNew Disposition = "NEW"
+	// DispositionListed means the auction is unchanged and still listed.
+	DispositionListed Disposition = "LISTED"
+	// DispositionQuantityReduced means part of the auction was bought, this only happens for commodities.
+	DispositionQuantityReduced Disposition = "QUANTITY_REDUCED"
+	// DispositionSold means the auction disappeared before it could have expired, so it was very likely
+	// bought. Cancelled auctions are indistinguishable from sales.
+	DispositionSold Disposition = "SOLD"
+	// DispositionExpired means the auction disappeared after it may have run out of time.
+	DispositionExpired Disposition = "EXPIRED"
+)
+
+// ClassifyDisappeared decides whether an auction which was last seen with lastSeen TimeLeft, and which
+// disappeared within elapsed, sold or expired. An auction is only considered sold if the least time it
+// could have had left is longer than elapsed.
+func ClassifyDisappeared(lastSeen wowapiclient.TimeLeft, elapsed time.Duration) Disposition {
+	if lastSeen.MinRemaining() > elapsed {
+		return DispositionSold
+	}
+	return DispositionExpired
+}
+
+// Change is the disposition of a single auction between two snapshots.
+type Change struct {
+	// Auction is the most recent observation of the auction.
+	Auction     wowapiclient.Auction
+	Disposition Disposition
+	// SoldQuantity is the number of units which were likely bought between the snapshots.
+	SoldQuantity int
+}
+
+// ItemStats summarizes the changes for all auctions of a single item.
+type ItemStats struct {
+	ItemID int
+	// Listed is the quantity that was available in the previous snapshot.
+	Listed int
+	// NewlyListed is the quantity that was newly listed since the previous snapshot.
+	NewlyListed int
+	// Sold is the estimated quantity bought between the snapshots.
+	Sold int
+	// SoldValue is the estimated copper spent on Sold.
+	SoldValue int
+	// Expired is the quantity which expired between the snapshots.
+	Expired int
+}
+
+// SellThrough is the fraction of the previously listed quantity which sold.
+func (s ItemStats) SellThrough() float64 {
+	if s.Listed == 0 {
+		return 0
+	}
+	return float64(s.Sold) / float64(s.Listed)
+}
+
+// Diff is the result of comparing two consecutive snapshots of a connected realm.
+type Diff struct {
+	RealmID int
+	Elapsed time.Duration
+	// Changes is ordered by auction ID.
+	Changes []Change
+	Items   map[int]ItemStats
+}
+
+// DiffSnapshots compares two consecutive snapshots of the same connected realm and classifies every
+// auction in either of them.
+func DiffSnapshots(prev, cur Snapshot) (Diff, error) {
+	if prev.RealmID != cur.RealmID {
+		return Diff{}, fmt.Errorf("cannot diff snapshots of different realms %v and %v", prev.RealmID, cur.RealmID)
+	}
+	elapsed := cur.Time.Sub(prev.Time)
+	if elapsed <= 0 {
+		return Diff{}, fmt.Errorf("snapshot at %v is not after snapshot at %v", cur.Time, prev.Time)
+	}
+
+	previous := make(map[int]wowapiclient.Auction, len(prev.Auctions))
+	for _, a := range prev.Auctions {
+		previous[a.ID] = a
+	}
+
+	d := Diff{
+		RealmID: cur.RealmID,
+		Elapsed: elapsed,
+		Changes: make([]Change, 0, len(cur.Auctions)),
+		Items:   make(map[int]ItemStats),
+	}
+
+	seen := make(map[int]struct{}, len(cur.Auctions))
+	for _, a := range cur.Auctions {
+		seen[a.ID] = struct{}{}
+		p, ok := previous[a.ID]
+		switch {
+		case !ok:
+			d.add(Change{Auction: a, Disposition: DispositionNew})
+		case a.Quantity < p.Quantity:
+			d.add(Change{Auction: a, Disposition: DispositionQuantityReduced, SoldQuantity: p.Quantity - a.Quantity})
+		default:
+			d.add(Change{Auction: a, Disposition: DispositionListed})
+		}
+	}
+
+	for _, p := range prev.Auctions {
+		if _, ok := seen[p.ID]; ok {
+			continue
+		}
+		c := Change{Auction: p, Disposition: ClassifyDisappeared(p.TimeLeft, elapsed)}
+		if c.Disposition == DispositionSold {
+			c.SoldQuantity = p.Quantity
+		}
+		d.add(c)
+	}
+
+	for _, p := range prev.Auctions {
+		stats := d.Items[p.ItemID]
+		stats.Listed += p.Quantity
+		d.Items[p.ItemID] = stats
+	}
+
+	sort.Slice(d.Changes, func(i, j int) bool {
+		return d.Changes[i].Auction.ID < d.Changes[j].Auction.ID
+	})
+
+	return d, nil
+}
+
+func (d *Diff) add(c Change) {
+	d.Changes = append(d.Changes, c)
+
+	stats := d.Items[c.Auction.ItemID]
+	stats.ItemID = c.Auction.ItemID
+	switch c.Disposition {
+	case DispositionNew:
+		stats.NewlyListed += c.Auction.Quantity
+	case DispositionExpired:
+		stats.Expired += c.Auction.Quantity
+	}
+	stats.Sold += c.SoldQuantity
+	stats.SoldValue += c.SoldQuantity * c.Auction.EffectiveUnitPrice()
+	d.Items[c.Auction.ItemID] = stats
+}
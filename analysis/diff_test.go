@@ -0,0 +1,71 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	prev := Snapshot{
+		RealmID: 61,
+		Time:    start,
+		Auctions: []wowapiclient.Auction{
+			{ID: 1, ItemID: 100, Quantity: 1, Buyout: 500, TimeLeft: wowapiclient.TimeLeftVeryLong},
+			{ID: 2, ItemID: 100, Quantity: 1, Buyout: 600, TimeLeft: wowapiclient.TimeLeftShort},
+			{ID: 3, ItemID: 200, Quantity: 50, UnitPrice: 10, TimeLeft: wowapiclient.TimeLeftLong},
+			{ID: 4, ItemID: 100, Quantity: 1, Buyout: 700, TimeLeft: wowapiclient.TimeLeftLong},
+		},
+	}
+	cur := Snapshot{
+		RealmID: 61,
+		Time:    start.Add(time.Hour),
+		Auctions: []wowapiclient.Auction{
+			{ID: 3, ItemID: 200, Quantity: 20, UnitPrice: 10, TimeLeft: wowapiclient.TimeLeftLong},
+			{ID: 4, ItemID: 100, Quantity: 1, Buyout: 700, TimeLeft: wowapiclient.TimeLeftLong},
+			{ID: 5, ItemID: 100, Quantity: 2, Buyout: 800, TimeLeft: wowapiclient.TimeLeftVeryLong},
+		},
+	}
+
+	d, err := DiffSnapshots(prev, cur)
+	if err != nil {
+		t.Fatalf("DiffSnapshots() error = %v", err)
+	}
+
+	want := map[int]Disposition{
+		1: DispositionSold,
+		2: DispositionExpired,
+		3: DispositionQuantityReduced,
+		4: DispositionListed,
+		5: DispositionNew,
+	}
+	if len(d.Changes) != len(want) {
+		t.Fatalf("DiffSnapshots() got %v changes, want %v", len(d.Changes), len(want))
+	}
+	for _, c := range d.Changes {
+		if c.Disposition != want[c.Auction.ID] {
+			t.Errorf("auction %v disposition = %v, want %v", c.Auction.ID, c.Disposition, want[c.Auction.ID])
+		}
+	}
+
+	stats := d.Items[100]
+	if stats.Listed != 3 || stats.NewlyListed != 2 || stats.Sold != 1 || stats.SoldValue != 500 || stats.Expired != 1 {
+		t.Errorf("item 100 stats = %+v", stats)
+	}
+	stats = d.Items[200]
+	if stats.Sold != 30 || stats.SoldValue != 300 || stats.SellThrough() != 0.6 {
+		t.Errorf("item 200 stats = %+v", stats)
+	}
+}
+
+func TestDiffSnapshotsErrors(t *testing.T) {
+	now := time.Now()
+	if _, err := DiffSnapshots(Snapshot{RealmID: 1, Time: now}, Snapshot{RealmID: 2, Time: now.Add(time.Hour)}); err == nil {
+		t.Error("DiffSnapshots() of different realms error = nil")
+	}
+	if _, err := DiffSnapshots(Snapshot{RealmID: 1, Time: now}, Snapshot{RealmID: 1, Time: now}); err == nil {
+		t.Error("DiffSnapshots() of simultaneous snapshots error = nil")
+	}
+}
@@ -0,0 +1,33 @@
+package analysis
+
+import "github.com/ZymoticB/wowauctiondata/wowapiclient"
+
+// BelowVendor returns the auctions whose EffectiveUnitPrice is below what a vendor pays for their item,
+// which can be bought and sold to a vendor for a profit. Auctions of items missing from details, or
+// which vendors do not buy, are skipped.
+func BelowVendor(auctions []wowapiclient.Auction, details map[int]wowapiclient.ItemDetail) []wowapiclient.Auction {
+	var below []wowapiclient.Auction
+	for _, a := range auctions {
+		d, ok := details[a.ItemID]
+		if !ok || d.SellPrice == 0 || !a.HasBuyout() {
+			continue
+		}
+		if a.EffectiveUnitPrice() < d.SellPrice {
+			below = append(below, a)
+		}
+	}
+	return below
+}
+
+// FilterByQuality returns the auctions whose item is at least min quality. Auctions of items missing
+// from details are skipped.
+func FilterByQuality(auctions []wowapiclient.Auction, details map[int]wowapiclient.ItemDetail, min wowapiclient.ItemQuality) []wowapiclient.Auction {
+	var filtered []wowapiclient.Auction
+	for _, a := range auctions {
+		d, ok := details[a.ItemID]
+		if ok && d.Quality.AtLeast(min) {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
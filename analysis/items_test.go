@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+func TestBelowVendor(t *testing.T) {
+	auctions := []wowapiclient.Auction{
+		// a stack of 20 for less than 20 times the vendor price
+		{ID: 1, ItemID: 10, Quantity: 20, UnitPrice: 12},
+		{ID: 2, ItemID: 10, Quantity: 20, UnitPrice: 13},
+		{ID: 3, ItemID: 20, Quantity: 1, Buyout: 90},
+		// bid only auctions cannot be bought outright
+		{ID: 4, ItemID: 20, Quantity: 1, Bid: 10},
+		// vendors do not buy item 30
+		{ID: 5, ItemID: 30, Quantity: 1, Buyout: 1},
+		// item 40 has no details
+		{ID: 6, ItemID: 40, Quantity: 1, Buyout: 1},
+	}
+	details := map[int]wowapiclient.ItemDetail{
+		10: {SellPrice: 13},
+		20: {SellPrice: 100},
+		30: {},
+	}
+
+	got := BelowVendor(auctions, details)
+	if ids := auctionIDs(got); !reflect.DeepEqual(ids, []int{1, 3}) {
+		t.Errorf("BelowVendor() = %v, want auctions [1 3]", ids)
+	}
+}
+
+func TestFilterByQuality(t *testing.T) {
+	auctions := []wowapiclient.Auction{
+		{ID: 1, ItemID: 10},
+		{ID: 2, ItemID: 20},
+		{ID: 3, ItemID: 30},
+		{ID: 4, ItemID: 40},
+		{ID: 5, ItemID: 50},
+	}
+	details := map[int]wowapiclient.ItemDetail{
+		10: {Quality: wowapiclient.QualityPoor},
+		20: {Quality: wowapiclient.QualityUncommon},
+		30: {Quality: wowapiclient.QualityEpic},
+		40: {Quality: "MYTHIC"},
+	}
+
+	tests := []struct {
+		min  wowapiclient.ItemQuality
+		want []int
+	}{
+		{min: wowapiclient.QualityPoor, want: []int{1, 2, 3}},
+		{min: wowapiclient.QualityUncommon, want: []int{2, 3}},
+		{min: wowapiclient.QualityRare, want: []int{3}},
+		{min: wowapiclient.QualityHeirloom},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.min), func(t *testing.T) {
+			got := auctionIDs(FilterByQuality(auctions, details, tt.min))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterByQuality() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func auctionIDs(auctions []wowapiclient.Auction) []int {
+	var ids []int
+	for _, a := range auctions {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
@@ -0,0 +1,122 @@
+// Package blobstore provides persistence for small named blobs such as caches and state files. It is
+// backed by memory or a local directory, package gcs provides a Store for Cloud Storage.
+package blobstore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned by a Store when the requested blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Store gets and puts blobs by name.
+type Store interface {
+	// Get returns the named blob or ErrNotFound.
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Put creates or replaces the named blob.
+	Put(ctx context.Context, name string, data []byte) error
+}
+
+// Memory is a Store which only lives as long as the process.
+type Memory struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+// NewMemory creates an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{blobs: make(map[string][]byte)}
+}
+
+// Get implements Store.
+func (m *Memory) Get(_ context.Context, name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.blobs[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// Put implements Store.
+func (m *Memory) Put(_ context.Context, name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blobs[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// Dir is a Store which keeps each blob as a file under the given directory. Names may contain slashes
+// to create subdirectories.
+type Dir string
+
+// Get implements Store.
+func (d Dir) Get(_ context.Context, name string) ([]byte, error) {
+	b, err := ioutil.ReadFile(d.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", name)
+	}
+	return b, nil
+}
+
+// Put implements Store. The blob is written to a temporary file first so readers never see a partial
+// blob.
+func (d Dir) Put(_ context.Context, name string, data []byte) error {
+	p := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %q", name)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p), filepath.Base(p)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %q", name)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to write %q", name)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %q", name)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return errors.Wrapf(err, "failed to write %q", name)
+	}
+	return nil
+}
+
+func (d Dir) path(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(name))
+}
+
+// WithPrefix returns a Store which prefixes every name with prefix before calling s, to keep
+// independent sets of blobs apart in one Store.
+func WithPrefix(s Store, prefix string) Store {
+	return prefixed{store: s, prefix: prefix}
+}
+
+type prefixed struct {
+	store  Store
+	prefix string
+}
+
+func (p prefixed) Get(ctx context.Context, name string) ([]byte, error) {
+	return p.store.Get(ctx, p.prefix+name)
+}
+
+func (p prefixed) Put(ctx context.Context, name string, data []byte) error {
+	return p.store.Put(ctx, p.prefix+name, data)
+}
@@ -0,0 +1,89 @@
+package blobstore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobstore")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{name: "memory", store: NewMemory()},
+		{name: "dir", store: Dir(dir)},
+		{name: "prefixed", store: WithPrefix(NewMemory(), "classic/")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := tt.store.Get(ctx, "missing.json"); err != ErrNotFound {
+				t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+			}
+
+			if err := tt.store.Put(ctx, "state/61.json", []byte("first")); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if err := tt.store.Put(ctx, "state/61.json", []byte("second")); err != nil {
+				t.Fatalf("Put() replacing a blob error = %v", err)
+			}
+
+			b, err := tt.store.Get(ctx, "state/61.json")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if string(b) != "second" {
+				t.Errorf("Get() = %q, want second", b)
+			}
+
+			// changing the returned blob must not change the stored one
+			b[0] = 'X'
+			if b, _ := tt.store.Get(ctx, "state/61.json"); string(b) != "second" {
+				t.Errorf("Get() after modifying a returned blob = %q, want second", b)
+			}
+		})
+	}
+}
+
+func TestDirLeavesNoTemporaryFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobstore")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := Dir(dir).Put(context.Background(), "cache.json", []byte("{}")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(files) != 1 || files[0].Name() != "cache.json" {
+		t.Errorf("Dir contains %v files, want only cache.json", len(files))
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+	if err := WithPrefix(m, "classic/").Put(ctx, "cache.json", []byte("{}")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := m.Get(ctx, "classic/cache.json"); err != nil {
+		t.Errorf("Get(classic/cache.json) error = %v", err)
+	}
+	if _, err := m.Get(ctx, "cache.json"); err != ErrNotFound {
+		t.Errorf("Get(cache.json) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,52 @@
+// Package gcs provides a blobstore.Store backed by a Cloud Storage bucket, for the cloud functions.
+package gcs
+
+import (
+	"context"
+	"io/ioutil"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/pkg/errors"
+)
+
+// Store is a blobstore.Store which keeps each blob as an object in a bucket.
+type Store struct {
+	bkt *storage.BucketHandle
+}
+
+// NewStore creates a Store for bkt.
+func NewStore(bkt *storage.BucketHandle) Store {
+	return Store{bkt: bkt}
+}
+
+// Get implements blobstore.Store.
+func (s Store) Get(ctx context.Context, name string) ([]byte, error) {
+	r, err := s.bkt.Object(name).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, blobstore.ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", name)
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", name)
+	}
+	return b, nil
+}
+
+// Put implements blobstore.Store.
+func (s Store) Put(ctx context.Context, name string, data []byte) error {
+	w := s.bkt.Object(name).NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return errors.Wrapf(err, "failed to write %q", name)
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+// Package cloudfunc holds what the cloud functions have in common: decoding their trigger messages,
+// fetching secrets, caching their Battle.net API token, writing CSVs to Cloud Storage and asking storagetobigtable to load them into
+// BigQuery.
+package cloudfunc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/blobstore/gcs"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+const (
+	_secretFetchTimeout = 5 * time.Second
+
+	_datasetID         = "wow_data"
+	_storageToBigTable = "storagetobigtable"
+
+	// _tokenBucketName is only readable by the service account of the cloud functions, unlike the data
+	// buckets, since the cached token is a bearer credential for the Battle.net API.
+	_tokenBucketName = "wow-oauth2-tokens"
+	_tokenCacheName  = "blizzard_oauth2_token.json"
+
+	_clientIDSecretNameFormat     = "projects/13595582905/secrets/blizzard-oauth-client-id%s/versions/latest"
+	_clientSecretSecretNameFormat = "projects/13595582905/secrets/blizzard-oauth-client-secret%s/versions/latest"
+
+	// WriteAppend appends the rows of a CSV to its table.
+	WriteAppend = "append"
+	// WriteTruncate replaces the rows of a table with those of a CSV.
+	WriteTruncate = "truncate"
+)
+
+// DecodeMessage decodes the JSON data of a pub/sub message into msg.
+func DecodeMessage(data []byte, msg interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(msg); err != nil {
+		return errors.Wrapf(err, "failed to decode json %q", string(data))
+	}
+	return nil
+}
+
+// FetchSecrets fetches secrets from the secretmanager API using the keys of toFetch as the
+// secret names. It mutates the given toFetch map.
+func FetchSecrets(ctx context.Context, toFetch map[string]string) error {
+	secretClient, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer secretClient.Close()
+
+	for name := range toFetch {
+		secret, err := fetchSecret(ctx, secretClient, name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch secret for %q", name)
+		}
+
+		toFetch[name] = secret
+	}
+
+	return nil
+}
+
+// _extraAPIClients are the comma separated names of Battle.net API clients whose request quota is pooled
+// with the original client's. The secrets of a client named n are suffixed with -n.
+var _extraAPIClients = os.Getenv("EXTRA_API_CLIENTS")
+
+// FetchAPICredentials fetches the credentials of the original Battle.net API client and of every client
+// in EXTRA_API_CLIENTS from the secretmanager API.
+func FetchAPICredentials(ctx context.Context) ([]wowapiclient.OAuth2Secrets, error) {
+	suffixes := []string{""}
+	for _, name := range strings.Split(_extraAPIClients, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			suffixes = append(suffixes, "-"+name)
+		}
+	}
+
+	secrets := make(map[string]string, 2*len(suffixes))
+	for _, s := range suffixes {
+		secrets[fmt.Sprintf(_clientIDSecretNameFormat, s)] = ""
+		secrets[fmt.Sprintf(_clientSecretSecretNameFormat, s)] = ""
+	}
+	if err := FetchSecrets(ctx, secrets); err != nil {
+		return nil, err
+	}
+
+	credentials := make([]wowapiclient.OAuth2Secrets, 0, len(suffixes))
+	for _, s := range suffixes {
+		credentials = append(credentials, wowapiclient.OAuth2Secrets{
+			ClientID:     secrets[fmt.Sprintf(_clientIDSecretNameFormat, s)],
+			ClientSecret: secrets[fmt.Sprintf(_clientSecretSecretNameFormat, s)],
+		})
+	}
+	return credentials, nil
+}
+
+// NewAPIHTTPClient creates an HTTP client for the Battle.net API whose oauth2 tokens are shared by every
+// instance of the cloud functions. Requests are spread over the credentials with a
+// wowapiclient.CredentialPool when there is more than one.
+func NewAPIHTTPClient(ctx context.Context, credentials []wowapiclient.OAuth2Secrets, region string, client *storage.Client) (*http.Client, error) {
+	if len(credentials) == 1 {
+		return wowapiclient.GetHTTPClient(ctx, credentials[0], region, tokenCache(client))
+	}
+
+	pool, err := wowapiclient.NewCredentialPool(ctx, credentials, region, tokenCache(client))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pool api credentials")
+	}
+	log.Printf("pooling %v of %v api credentials", len(pool.InRotation()), len(credentials))
+	return pool.Client(), nil
+}
+
+// tokenCache shares the Battle.net API oauth2 token between every instance of the cloud functions,
+// see wowapiclient.WithTokenCache.
+func tokenCache(client *storage.Client) wowapiclient.HTTPClientOption {
+	return wowapiclient.WithTokenCache(gcs.NewStore(client.Bucket(_tokenBucketName)), _tokenCacheName)
+}
+
+func fetchSecret(ctx context.Context, secretClient *secretmanager.Client, name string) (string, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, _secretFetchTimeout)
+	defer cancel()
+
+	secret, err := secretClient.AccessSecretVersion(fetchCtx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: name,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to fetch %q", name)
+	}
+
+	payload := secret.GetPayload()
+	if payload == nil {
+		return "", fmt.Errorf("secret %q returned an empty payload", name)
+	}
+
+	data := payload.GetData()
+	if len(data) == 0 {
+		return "", fmt.Errorf("secret %q returned empty data", name)
+	}
+
+	return string(data), nil
+}
+
+// WriteCSV writes rows to the named object in bkt and returns its gs:// reference.
+func WriteCSV(ctx context.Context, bkt *storage.BucketHandle, name string, rows [][]string) (string, error) {
+	obj := bkt.Object(name)
+	writer := obj.NewWriter(ctx)
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return "", errors.Wrap(err, "failed to write to storage")
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to write to storage")
+	}
+
+	return fmt.Sprintf("gs://%s/%s", obj.BucketName(), name), nil
+}
+
+// FormatTime formats t for a BigQuery TIMESTAMP column, the zero time is written as an empty (NULL) value.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// loadMessage asks storagetobigtable to load a CSV into a BigQuery table.
+type loadMessage struct {
+	// expected to be of the form gs://...
+	GCSReference string `json:"gcsReference"`
+	DatasetID    string `json:"datasetID"`
+	TableID      string `json:"tableID"`
+	WriteMode    string `json:"writeMode"`
+}
+
+// Publisher publishes Pub/Sub messages with a client which is created on first use rather than when the
+// function instance starts, so packages which hold one can be loaded without GCP credentials, for
+// example by tests. It is meant to be kept for the life of the instance.
+type Publisher struct {
+	projectID string
+
+	once      sync.Once
+	client    *pubsub.Client
+	clientErr error
+}
+
+// NewPublisher creates a Publisher for projectID without connecting to Pub/Sub.
+func NewPublisher(projectID string) *Publisher {
+	return &Publisher{projectID: projectID}
+}
+
+func (p *Publisher) getClient() (*pubsub.Client, error) {
+	p.once.Do(func() {
+		// client is initialized with context.Background() because it should
+		// persist between function invocations.
+		p.client, p.clientErr = pubsub.NewClient(context.Background(), p.projectID)
+	})
+	return p.client, p.clientErr
+}
+
+// NotifyStorageToBigQuery publishes a message for storagetobigtable to load the CSV at gcsRef into
+// tableID of the wow_data dataset, with writeMode WriteAppend or WriteTruncate.
+func NotifyStorageToBigQuery(ctx context.Context, publisher *Publisher, gcsRef string, tableID string, writeMode string) error {
+	msg := loadMessage{
+		GCSReference: gcsRef,
+		DatasetID:    _datasetID,
+		TableID:      tableID,
+		WriteMode:    writeMode,
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal pubsub message")
+	}
+
+	client, err := publisher.getClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create pubsub client")
+	}
+
+	t := client.Topic(_storageToBigTable)
+	_, err = t.Publish(ctx, &pubsub.Message{
+		Data: b,
+	}).Get(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to publish message to pubsub")
+	}
+
+	log.Printf("wrote message to storagetobigtable")
+	return nil
+}
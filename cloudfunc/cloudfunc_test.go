@@ -0,0 +1,38 @@
+package cloudfunc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTime(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{name: "zero", want: ""},
+		{name: "utc", t: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC), want: "2020-10-01T12:00:00Z"},
+		{name: "other zone", t: time.Date(2020, 10, 1, 8, 0, 0, 0, time.FixedZone("EDT", -4*60*60)), want: "2020-10-01T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTime(tt.t); got != tt.want {
+				t.Errorf("FormatTime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	msg := struct {
+		Target string `json:"target"`
+	}{}
+	if err := DecodeMessage([]byte(`{"target": "fetch-realms"}`), &msg); err != nil || msg.Target != "fetch-realms" {
+		t.Errorf("DecodeMessage() = %+v, %v", msg, err)
+	}
+	if err := DecodeMessage([]byte(`{"target"`), &msg); err == nil {
+		t.Error("DecodeMessage() of malformed json error = nil")
+	}
+}
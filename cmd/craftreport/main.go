@@ -0,0 +1,231 @@
+// Command craftreport ranks the recipes of a profession skill tier by how profitable they are to
+// craft and sell on a connected realm, using an auctions snapshot written by fetchauctions.
+//
+// Battle.net API credentials are read from BLIZZARD_CLIENT_ID and BLIZZARD_CLIENT_SECRET. API responses
+// can be recorded to a cassette with -cassette and -record, and replayed later without credentials with
+// -cassette alone.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/analysis"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/cassette"
+	"github.com/pkg/errors"
+)
+
+const (
+	_formatCSV  = "csv"
+	_formatJSON = "json"
+)
+
+func main() {
+	snapshotPath := flag.String("snapshot", "", "auctions CSV written by fetchauctions")
+	previousPath := flag.String("previous", "", "optional earlier auctions CSV used to estimate sell through")
+	snapshotTime := flag.String("snapshot-time", "", "RFC 3339 time -snapshot was taken, required with -previous")
+	previousTime := flag.String("previous-time", "", "RFC 3339 time -previous was taken, required with -previous")
+	realmID := flag.Int("realm", 0, "connected realm ID, defaults to the realm of the first auction in -snapshot")
+	professionID := flag.Int("profession", 0, "profession ID")
+	skillTierID := flag.Int("skill-tier", 0, "profession skill tier ID")
+	region := flag.String("region", "us", "API region")
+	format := flag.String("format", _formatCSV, "output format, csv or json")
+	cassettePath := flag.String("cassette", "", "replay API responses from this file")
+	record := flag.Bool("record", false, "record API responses to -cassette rather than replaying them")
+	flag.Parse()
+
+	if *snapshotPath == "" || *professionID == 0 || *skillTierID == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format != _formatCSV && *format != _formatJSON {
+		log.Fatalf("unknown format %q", *format)
+	}
+	if *record && *cassettePath == "" {
+		log.Fatal("-record requires -cassette")
+	}
+
+	auctions, err := readAuctions(*snapshotPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *realmID == 0 && len(auctions) > 0 {
+		*realmID = auctions[0].RealmID
+	}
+	auctions = realmAuctions(auctions, *realmID)
+
+	var sellThrough map[int]float64
+	if *previousPath != "" {
+		// the auctions CSVs carry no time, so sell through can only be estimated from explicit times
+		if *snapshotTime == "" || *previousTime == "" {
+			log.Fatal("-previous requires -snapshot-time and -previous-time")
+		}
+		curAt, err := time.Parse(time.RFC3339, *snapshotTime)
+		if err != nil {
+			log.Fatalf("invalid -snapshot-time: %v", err)
+		}
+		prevAt, err := time.Parse(time.RFC3339, *previousTime)
+		if err != nil {
+			log.Fatalf("invalid -previous-time: %v", err)
+		}
+
+		previous, err := readAuctions(*previousPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		diff, err := analysis.DiffSnapshots(
+			analysis.Snapshot{RealmID: *realmID, Time: prevAt, Auctions: realmAuctions(previous, *realmID)},
+			analysis.Snapshot{RealmID: *realmID, Time: curAt, Auctions: auctions},
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sellThrough = make(map[int]float64, len(diff.Items))
+		for id, stats := range diff.Items {
+			sellThrough[id] = stats.SellThrough()
+		}
+	}
+
+	var opts []wowapiclient.HTTPClientOption
+	saveCassette := func() error { return nil }
+	if *cassettePath != "" {
+		var transport http.RoundTripper
+		transport, saveCassette, err = cassette.NewTransport(*cassettePath, *record)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, wowapiclient.WithTransport(transport))
+	}
+
+	recipes, details, err := fetchRecipes(context.Background(), *region, *professionID, *skillTierID, opts)
+	// save whatever was recorded even if the API calls failed, so that the failure can be replayed
+	if err := saveCassette(); err != nil {
+		log.Printf("failed to save cassette: %v", err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	profits := analysis.CraftingProfits(*realmID, recipes, analysis.NewMarketPrices(auctions), details, sellThrough)
+	log.Printf("priced %v of %v recipes", len(profits), len(recipes))
+
+	if *format == _formatJSON {
+		err = writeJSON(os.Stdout, profits)
+	} else {
+		err = writeCSV(os.Stdout, profits)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readAuctions(path string) ([]wowapiclient.Auction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open auctions")
+	}
+	defer f.Close()
+
+	auctions, err := analysis.ReadAuctionsCSV(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %v", path)
+	}
+	return auctions, nil
+}
+
+func realmAuctions(auctions []wowapiclient.Auction, realmID int) []wowapiclient.Auction {
+	filtered := make([]wowapiclient.Auction, 0, len(auctions))
+	for _, a := range auctions {
+		if a.RealmID == realmID {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// fetchRecipes gets every recipe in a skill tier along with the details of the items they craft.
+func fetchRecipes(ctx context.Context, region string, professionID, skillTierID int, opts []wowapiclient.HTTPClientOption) ([]wowapiclient.Recipe, map[int]wowapiclient.ItemDetail, error) {
+	httpClient, err := wowapiclient.GetHTTPClient(ctx, wowapiclient.OAuth2Secrets{
+		ClientID:     os.Getenv("BLIZZARD_CLIENT_ID"),
+		ClientSecret: os.Getenv("BLIZZARD_CLIENT_SECRET"),
+	}, region, opts...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get oauth2 http client")
+	}
+	apiClient := wowapiclient.NewWOWAPIClient(httpClient, region)
+
+	tier, err := apiClient.GetProfessionSkillTier(professionID, skillTierID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get skill tier %v of profession %v", skillTierID, professionID)
+	}
+
+	var recipes []wowapiclient.Recipe
+	details := make(map[int]wowapiclient.ItemDetail)
+	for _, category := range tier.Categories {
+		for _, ref := range category.Recipes {
+			recipe, err := apiClient.GetRecipe(ref.ID)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to get recipe %v", ref.ID)
+			}
+			recipes = append(recipes, recipe)
+
+			for _, id := range []int{recipe.CraftedItemID, recipe.AllianceCraftedItemID} {
+				if _, ok := details[id]; id == 0 || ok {
+					continue
+				}
+				detail, err := apiClient.GetItemDetail(id)
+				if err != nil {
+					return nil, nil, errors.Wrapf(err, "failed to get item %v", id)
+				}
+				details[id] = detail
+			}
+		}
+	}
+	return recipes, details, nil
+}
+
+func writeJSON(w io.Writer, profits []analysis.CraftingProfit) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(profits); err != nil {
+		return errors.Wrap(err, "failed to write json")
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, profits []analysis.CraftingProfit) error {
+	csvWriter := csv.NewWriter(w)
+	rows := [][]string{{
+		"realm", "recipe", "recipe_name", "item", "quantity", "reagent_cost", "sale_price",
+		"ah_cut", "deposit", "margin", "sell_through",
+	}}
+	for _, p := range profits {
+		rows = append(rows, []string{
+			strconv.Itoa(p.RealmID),
+			strconv.Itoa(p.RecipeID),
+			p.RecipeName,
+			strconv.Itoa(p.CraftedItemID),
+			strconv.FormatFloat(p.CraftedQuantity, 'f', -1, 64),
+			strconv.Itoa(p.ReagentCost),
+			strconv.Itoa(p.SalePrice),
+			strconv.Itoa(p.AuctionHouseCut),
+			strconv.Itoa(p.Deposit),
+			strconv.Itoa(p.Margin),
+			fmt.Sprintf("%.3f", p.SellThrough),
+		})
+	}
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return errors.Wrap(err, "failed to write csv")
+	}
+	return nil
+}
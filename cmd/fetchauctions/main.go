@@ -0,0 +1,102 @@
+// Command fetchauctions fetches the auctions of a connected realm and writes them to stdout in the CSV
+// format of the fetchauctions cloud function, without touching Cloud Storage or BigQuery. By default an
+// auction which fails validation fails the command; with -quarantine such auctions are logged rather
+// than written, as the cloud function does.
+//
+// Battle.net API credentials are read from BLIZZARD_CLIENT_ID and BLIZZARD_CLIENT_SECRET. API responses
+// can be recorded to a cassette with -cassette and -record, and replayed later without credentials with
+// -cassette alone, which reproduces parsing failures of production responses.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/ZymoticB/wowauctiondata/analysis"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/cassette"
+	"github.com/pkg/errors"
+)
+
+const _zuljinID = 61
+
+func main() {
+	realmID := flag.Int("realm", 0, "connected realm ID, retail Zul'jin by default, required for classic flavors")
+	flavor := flag.String("flavor", string(wowapiclient.FlavorRetail), "game flavor")
+	region := flag.String("region", "us", "API region")
+	cassettePath := flag.String("cassette", "", "replay API responses from this file")
+	record := flag.Bool("record", false, "record API responses to -cassette rather than replaying them")
+	quarantine := flag.Bool("quarantine", false, "log auctions which fail validation rather than failing")
+	flag.Parse()
+
+	f := wowapiclient.GameFlavor(*flavor)
+	if *realmID == 0 {
+		if f != wowapiclient.FlavorRetail {
+			log.Fatalf("-realm is required for %v auctions", f)
+		}
+		*realmID = _zuljinID
+	}
+	if *record && *cassettePath == "" {
+		log.Fatal("-record requires -cassette")
+	}
+
+	var opts []wowapiclient.HTTPClientOption
+	saveCassette := func() error { return nil }
+	if *cassettePath != "" {
+		var (
+			transport http.RoundTripper
+			err       error
+		)
+		transport, saveCassette, err = cassette.NewTransport(*cassettePath, *record)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, wowapiclient.WithTransport(transport))
+	}
+
+	var (
+		rejects  wowapiclient.Quarantine
+		callOpts []wowapiclient.CallOption
+	)
+	if *quarantine {
+		callOpts = append(callOpts, wowapiclient.WithQuarantine(&rejects))
+	}
+	auctions, err := fetchAuctions(context.Background(), *region, f, *realmID, opts, callOpts...)
+	// save whatever was recorded even if the API calls failed, so that the failure can be replayed
+	if err := saveCassette(); err != nil {
+		log.Printf("failed to save cassette: %v", err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, r := range rejects {
+		log.Printf("quarantined auction %v of item %v, kept %v: %v", r.Auction.ID, r.Auction.ItemID, r.Kept, r.Reason)
+	}
+	log.Printf("got %v auctions, rejected %v", len(auctions), rejects.Rejected())
+
+	if err := analysis.WriteAuctionsCSV(os.Stdout, auctions); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fetchAuctions fetches every auction on a connected realm in flavor, passing opts to each API call.
+func fetchAuctions(ctx context.Context, region string, flavor wowapiclient.GameFlavor, realmID int, httpOpts []wowapiclient.HTTPClientOption, opts ...wowapiclient.CallOption) ([]wowapiclient.Auction, error) {
+	httpClient, err := wowapiclient.GetHTTPClient(ctx, wowapiclient.OAuth2Secrets{
+		ClientID:     os.Getenv("BLIZZARD_CLIENT_ID"),
+		ClientSecret: os.Getenv("BLIZZARD_CLIENT_SECRET"),
+	}, region, httpOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get oauth2 http client")
+	}
+	apiClient := wowapiclient.NewWOWAPIClient(httpClient, region, wowapiclient.WithFlavor(flavor))
+
+	auctions, err := apiClient.GetAllAuctions(realmID, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get auctions of %v", realmID)
+	}
+	return auctions, nil
+}
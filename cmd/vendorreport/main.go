@@ -0,0 +1,152 @@
+// Command vendorreport lists the auctions of a connected realm which are listed below what a vendor pays
+// for their item, using an auctions snapshot written by fetchauctions. Such auctions can be bought and
+// sold to a vendor for a profit.
+//
+// Battle.net API credentials are read from BLIZZARD_CLIENT_ID and BLIZZARD_CLIENT_SECRET. API responses
+// can be recorded to a cassette with -cassette and -record, and replayed later without credentials with
+// -cassette alone.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/ZymoticB/wowauctiondata/analysis"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/cassette"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	snapshotPath := flag.String("snapshot", "", "auctions CSV written by fetchauctions")
+	realmID := flag.Int("realm", 0, "connected realm ID, defaults to the realm of the first auction in -snapshot")
+	minQuality := flag.String("min-quality", string(wowapiclient.QualityPoor), "only list items of at least this quality, such as COMMON")
+	region := flag.String("region", "us", "API region")
+	cassettePath := flag.String("cassette", "", "replay API responses from this file")
+	record := flag.Bool("record", false, "record API responses to -cassette rather than replaying them")
+	flag.Parse()
+
+	if *snapshotPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	// every known quality is at least poor
+	if !wowapiclient.ItemQuality(*minQuality).AtLeast(wowapiclient.QualityPoor) {
+		log.Fatalf("unknown quality %q", *minQuality)
+	}
+	if *record && *cassettePath == "" {
+		log.Fatal("-record requires -cassette")
+	}
+
+	f, err := os.Open(*snapshotPath)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to open auctions"))
+	}
+	auctions, err := analysis.ReadAuctionsCSV(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to read %v", *snapshotPath))
+	}
+	if *realmID == 0 && len(auctions) > 0 {
+		*realmID = auctions[0].RealmID
+	}
+	var realm []wowapiclient.Auction
+	for _, a := range auctions {
+		if a.RealmID == *realmID {
+			realm = append(realm, a)
+		}
+	}
+
+	var opts []wowapiclient.HTTPClientOption
+	saveCassette := func() error { return nil }
+	if *cassettePath != "" {
+		var transport http.RoundTripper
+		transport, saveCassette, err = cassette.NewTransport(*cassettePath, *record)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, wowapiclient.WithTransport(transport))
+	}
+
+	details, err := fetchItemDetails(context.Background(), *region, realm, opts)
+	// save whatever was recorded even if the API calls failed, so that the failure can be replayed
+	if err := saveCassette(); err != nil {
+		log.Printf("failed to save cassette: %v", err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	below := analysis.BelowVendor(analysis.FilterByQuality(realm, details, wowapiclient.ItemQuality(*minQuality)), details)
+	log.Printf("%v of %v auctions are below vendor value", len(below), len(realm))
+
+	if err := writeCSV(os.Stdout, below, details); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fetchItemDetails gets the details of every item in auctions.
+func fetchItemDetails(ctx context.Context, region string, auctions []wowapiclient.Auction, opts []wowapiclient.HTTPClientOption) (map[int]wowapiclient.ItemDetail, error) {
+	httpClient, err := wowapiclient.GetHTTPClient(ctx, wowapiclient.OAuth2Secrets{
+		ClientID:     os.Getenv("BLIZZARD_CLIENT_ID"),
+		ClientSecret: os.Getenv("BLIZZARD_CLIENT_SECRET"),
+	}, region, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get oauth2 http client")
+	}
+	apiClient := wowapiclient.NewWOWAPIClient(httpClient, region)
+
+	// look items up in ID order so that a recorded cassette replays in the same order
+	var ids []int
+	seen := make(map[int]bool)
+	for _, a := range auctions {
+		if !seen[a.ItemID] {
+			seen[a.ItemID] = true
+			ids = append(ids, a.ItemID)
+		}
+	}
+	sort.Ints(ids)
+
+	details := make(map[int]wowapiclient.ItemDetail, len(ids))
+	for _, id := range ids {
+		detail, err := apiClient.GetItemDetail(id)
+		if wowapiclient.IsNotFound(err) {
+			// deleted items are left out like items vendors do not buy
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get item %v", id)
+		}
+		details[id] = detail
+	}
+	return details, nil
+}
+
+func writeCSV(w io.Writer, auctions []wowapiclient.Auction, details map[int]wowapiclient.ItemDetail) error {
+	csvWriter := csv.NewWriter(w)
+	rows := [][]string{{"realm", "auction", "item", "item_name", "quantity", "unit_price", "sell_price", "profit"}}
+	for _, a := range auctions {
+		d := details[a.ItemID]
+		rows = append(rows, []string{
+			strconv.Itoa(a.RealmID),
+			strconv.Itoa(a.ID),
+			strconv.Itoa(a.ItemID),
+			d.Name.String(),
+			strconv.Itoa(a.Quantity),
+			strconv.Itoa(a.EffectiveUnitPrice()),
+			strconv.Itoa(d.SellPrice),
+			strconv.Itoa((d.SellPrice - a.EffectiveUnitPrice()) * a.Quantity),
+		})
+	}
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return errors.Wrap(err, "failed to write csv")
+	}
+	return nil
+}
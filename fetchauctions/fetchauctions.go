@@ -1,30 +1,26 @@
 package fetchrealms
 
 import (
-	"bytes"
 	"context"
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/pubsub"
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/analysis"
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/blobstore/gcs"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/ZymoticB/wowauctiondata/itemcache"
+	"github.com/ZymoticB/wowauctiondata/lifecycle"
 	"github.com/ZymoticB/wowauctiondata/wowapiclient"
 	"github.com/pkg/errors"
-	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
 )
 
 const (
-	_targetName         = "fetch-auctions"
-	_secretFetchTimeout = 5 * time.Second
-
-	_clientIDSecretName     = "projects/13595582905/secrets/blizzard-oauth-client-id/versions/latest"
-	_clientSecretSecretName = "projects/13595582905/secrets/blizzard-oauth-client-secret/versions/latest"
+	_targetName = "fetch-auctions"
 
 	_destBucketName = "wow-realm-data"
 	_destFileName   = "auctions"
@@ -32,9 +28,12 @@ const (
 	_region   = "us"
 	_zuljinID = 61
 
-	_datasetID   = "wow_data"
-	_tableID     = "auctions"
-	_writeAppend = "append"
+	_lifecyclesFileName = "auction_lifecycles"
+	_rejectsFileName    = "auction_rejects"
+
+	_tableID           = "auctions"
+	_lifecyclesTableID = "auction_lifecycles"
+	_itemsTableID      = "items"
 )
 
 var _projectID = os.Getenv("GCP_PROJECT")
@@ -48,24 +47,17 @@ type PubSubContainer struct {
 // PubSubMessage is the decoded pub/sub message sent to the application
 type PubSubMessage struct {
 	Target string `json:"target"`
+	// Flavor and ConnectedRealmID select the auctions to fetch, retail Zul'jin by default. Connected
+	// realm IDs differ between flavors, so ConnectedRealmID is required for classic flavors.
+	Flavor           wowapiclient.GameFlavor `json:"flavor"`
+	ConnectedRealmID int                     `json:"connectedRealmID"`
 }
 
-// pubsubClient is a global Pub/Sub client, initialized once per instance.
-var pubsubClient *pubsub.Client
-
-var itemCache map[int]wowapiclient.Item
+// publisher notifies storagetobigtable, it is kept for the life of the instance.
+var publisher = cloudfunc.NewPublisher(_projectID)
 
-func init() {
-	// err is pre-declared to avoid shadowing client.
-	var err error
-
-	// client is initialized with context.Background() because it should
-	// persist between function invocations.
-	pubsubClient, err = pubsub.NewClient(context.Background(), _projectID)
-	if err != nil {
-		log.Fatalf("pubsub.NewClient: %v", err)
-	}
-}
+// itemCache is loaded from storage by the first invocation and kept for the life of the instance.
+var itemCache *itemcache.Cache
 
 // FetchAuctions is a cloud function to fetch all wow realms
 func FetchAuctions(ctx context.Context, m PubSubContainer) error {
@@ -74,7 +66,8 @@ func FetchAuctions(ctx context.Context, m PubSubContainer) error {
 		return nil
 	}
 
-	msg, err := getMessage(m)
+	msg := PubSubMessage{}
+	err := cloudfunc.DecodeMessage(m.Data, &msg)
 	if err != nil {
 		log.Printf("failed to get message from pub/sub message: %v", err)
 		return err
@@ -85,177 +78,228 @@ func FetchAuctions(ctx context.Context, m PubSubContainer) error {
 		return nil
 	}
 
-	secrets := map[string]string{
-		_clientIDSecretName:     "",
-		_clientSecretSecretName: "",
+	if err := msg.setDefaults(); err != nil {
+		log.Printf("invalid message: %v", err)
+		return err
 	}
 
-	err = fetchSecrets(ctx, secrets)
+	credentials, err := cloudfunc.FetchAPICredentials(ctx)
 	if err != nil {
 		log.Printf("failed to fetch secrets: %v", err)
 		return err
 	}
 
-	realms, err := fetchAuctions(ctx, secrets)
+	client, err := storage.NewClient(ctx)
 	if err != nil {
-		log.Printf("failed to fetch realms: %v", err)
-		return err
+		return errors.Wrap(err, "failed to create gcp client")
 	}
-	log.Printf("Got realms %v", realms)
+	defer client.Close()
+	bkt := client.Bucket(_destBucketName)
 
-	gcsRef, err := writeRealmsToStorage(ctx, realms)
+	apiClient, err := newAPIClient(ctx, credentials, msg.Flavor, client)
 	if err != nil {
-		log.Printf("failed to write to storage: %v", err)
 		return err
 	}
 
-	if err := notifyStorageToBigQuery(ctx, gcsRef); err != nil {
-		return errors.Wrap(err, "failed to notify storagetobigquery")
-	}
-
-	log.Printf("successfully wrote realms to storage")
-	return nil
-}
-
-func writeRealmsToStorage(ctx context.Context, auctions []wowapiclient.Auction) (string, error) {
-	client, err := storage.NewClient(ctx)
+	snapshotTime := time.Now()
+	auctions, rejects, err := fetchAuctions(apiClient, msg.ConnectedRealmID)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to create gcp client")
+		log.Printf("failed to fetch realms: %v", err)
+		return err
 	}
-	bkt := client.Bucket(_destBucketName)
-	obj := bkt.Object(_destFileName)
-	writer := obj.NewWriter(ctx)
-	csvWriter := csv.NewWriter(writer)
-	for _, a := range auctions {
-		row := []string{
-			strconv.Itoa(a.ID),
-			strconv.Itoa(a.ItemID),
-			strconv.Itoa(a.Quantity),
-			strconv.Itoa(a.UnitPrice),
-			strconv.Itoa(a.Buyout),
-			strconv.Itoa(a.Bid),
-			string(a.TimeLeft),
-			strconv.Itoa(a.RealmID),
+	log.Printf("Got %v auctions", len(auctions))
+
+	if len(rejects) > 0 {
+		for _, r := range rejects {
+			if r.Kept {
+				log.Printf("kept auction %v with an unknown time left: %v", r.Auction.ID, r.Reason)
+			}
 		}
-		err := csvWriter.Write(row)
+		// rejects are kept for inspection only, failing to write them should not lose the snapshot
+		gcsRef, err := writeRejectsToStorage(ctx, bkt, msg.Flavor, snapshotTime, rejects)
 		if err != nil {
-			return "", errors.Wrap(err, "failed to write to storage")
+			log.Printf("failed to write %v quarantined auctions to storage: %v", len(rejects), err)
+		} else {
+			log.Printf("rejected %v auctions and kept %v with an unknown time left, wrote them to %v",
+				rejects.Rejected(), len(rejects)-rejects.Rejected(), gcsRef)
 		}
 	}
 
-	csvWriter.Flush()
-	if err := csvWriter.Error(); err != nil {
-		return "", errors.Wrap(err, "failed to write to storage")
+	gcsRef, err := writeAuctionsToStorage(ctx, bkt, msg.Flavor, auctions)
+	if err != nil {
+		log.Printf("failed to write to storage: %v", err)
+		return err
 	}
 
-	if err := writer.Close(); err != nil {
-		return "", errors.Wrap(err, "failed to write to storage")
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _tableID, cloudfunc.WriteAppend); err != nil {
+		return errors.Wrap(err, "failed to notify storagetobigquery")
 	}
 
-	return fmt.Sprintf("gs://%s/%s", _destBucketName, _destFileName), nil
-}
+	log.Printf("successfully wrote realms to storage")
 
-// TODO move this to a shared module
-type pubSubMessage struct {
-	// expected to be of the form gs://...
-	GCSReference string `json:"gcsReference"`
-	DatasetID    string `json:"datasetID"`
-	TableID      string `json:"tableID"`
-	WriteMode    string `json:"writeMode"`
-}
+	// The snapshot has been appended, so returning an error from here on would have Pub/Sub retry the
+	// invocation and append it a second time. Items and lifecycles are best effort instead, items which
+	// are not looked up are looked up by the next invocation and a missed snapshot only makes the next
+	// lifecycle diff span a longer time.
 
-func notifyStorageToBigQuery(ctx context.Context, gcsRef string) error {
-	msg := pubSubMessage{
-		GCSReference: gcsRef,
-		DatasetID:    _datasetID,
-		TableID:      _tableID,
-		WriteMode:    _writeAppend,
-	}
-	b, err := json.Marshal(msg)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal pubsub message")
+	// the items table only holds retail items
+	if msg.Flavor == wowapiclient.FlavorRetail {
+		if err := enrichItems(ctx, apiClient, bkt, auctions); err != nil {
+			log.Printf("failed to enrich items: %v", err)
+		}
 	}
 
-	t := pubsubClient.Topic("storagetobigtable")
-	_, err = t.Publish(ctx, &pubsub.Message{
-		Data: b,
-	}).Get(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to publish message to pubsub")
+	if err := trackLifecycles(ctx, bkt, msg.Flavor, analysis.Snapshot{
+		RealmID:  msg.ConnectedRealmID,
+		Time:     snapshotTime,
+		Auctions: auctions,
+	}); err != nil {
+		log.Printf("failed to track auction lifecycles: %v", err)
 	}
-
-	log.Printf("wrote message to storagetobigtable")
 	return nil
 }
 
-func fetchAuctions(ctx context.Context, secrets map[string]string) ([]wowapiclient.Auction, error) {
-	httpClient, err := wowapiclient.GetHTTPClient(ctx, wowapiclient.OAuth2Secrets{
-		ClientID:     secrets[_clientIDSecretName],
-		ClientSecret: secrets[_clientSecretSecretName],
-	}, _region)
+// trackLifecycles applies snap to the tracked auction lifecycles of its realm and loads the lifecycles
+// of auctions which ended into BigQuery.
+func trackLifecycles(ctx context.Context, bkt *storage.BucketHandle, flavor wowapiclient.GameFlavor, snap analysis.Snapshot) error {
+	tracker := lifecycle.NewTracker(flavorStore(gcs.NewStore(bkt), flavor))
+	o, err := tracker.Observe(ctx, snap)
 	if err != nil {
-		log.Printf("failed to get oauth2 http client %v", err)
-		return nil, err
+		return err
 	}
 
-	apiClient := wowapiclient.NewWOWAPIClient(httpClient, _region)
+	if len(o.Completed) == 0 {
+		log.Printf("no auction lifecycles completed")
+	} else {
+		gcsRef, err := writeLifecyclesToStorage(ctx, bkt, flavor, o.Completed)
+		if err != nil {
+			return errors.Wrap(err, "failed to write lifecycles to storage")
+		}
+		if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _lifecyclesTableID, cloudfunc.WriteAppend); err != nil {
+			return errors.Wrap(err, "failed to notify storagetobigquery")
+		}
+		log.Printf("successfully wrote %v auction lifecycles to storage", len(o.Completed))
+	}
 
-	return apiClient.GetAuctions(_zuljinID)
+	// the state only advances once the completed lifecycles have been loaded, so that they are not lost
+	return tracker.Commit(ctx, o)
 }
 
-func getMessage(m PubSubContainer) (PubSubMessage, error) {
-	msg := PubSubMessage{}
+// fetchAuctions fetches every auction on a connected realm in the flavor of apiClient. Auctions which
+// fail validation are returned separately rather than failing the snapshot.
+func fetchAuctions(apiClient *wowapiclient.WOWAPIClient, realmID int) ([]wowapiclient.Auction, wowapiclient.Quarantine, error) {
+	var rejects wowapiclient.Quarantine
+	auctions, err := apiClient.GetAllAuctions(realmID, wowapiclient.WithQuarantine(&rejects))
+	return auctions, rejects, err
+}
 
-	decoder := json.NewDecoder(bytes.NewReader(m.Data))
-	err := decoder.Decode(&msg)
-	if err != nil {
-		return PubSubMessage{}, errors.Wrapf(err, "failed to decode json %q", string(m.Data))
+// flavorObjectName keeps objects of classic flavors apart from retail ones, which keep their original
+// names.
+func flavorObjectName(name string, flavor wowapiclient.GameFlavor) string {
+	if flavor == wowapiclient.FlavorRetail {
+		return name
 	}
-	return msg, nil
+	return fmt.Sprintf("%s_%s", name, flavor)
 }
 
-// fetchSecrets fetches secrets from the secretmanager API using the keys of toFetch as the
-// secret names. It mutates the given toFetch map.
-func fetchSecrets(ctx context.Context, toFetch map[string]string) error {
-	secretClient, err := secretmanager.NewClient(ctx)
-	defer secretClient.Close()
+// flavorStore keeps state of classic flavors apart from retail state, which keeps its original names.
+func flavorStore(store blobstore.Store, flavor wowapiclient.GameFlavor) blobstore.Store {
+	if flavor == wowapiclient.FlavorRetail {
+		return store
+	}
+	return blobstore.WithPrefix(store, fmt.Sprintf("%s/", flavor))
+}
 
-	if err != nil {
-		return err
+func writeAuctionsToStorage(ctx context.Context, bkt *storage.BucketHandle, flavor wowapiclient.GameFlavor, auctions []wowapiclient.Auction) (string, error) {
+	rows := make([][]string, 0, len(auctions))
+	for _, a := range auctions {
+		rows = append(rows, analysis.AuctionRecord(a))
 	}
 
-	for name := range toFetch {
-		secret, err := fetchSecret(ctx, secretClient, name)
-		if err != nil {
-			return errors.Wrapf(err, "failed to fetch secret for %q", name)
-		}
+	return cloudfunc.WriteCSV(ctx, bkt, flavorObjectName(_destFileName, flavor), rows)
+}
 
-		toFetch[name] = secret
+// writeRejectsToStorage writes quarantined auctions, with the reason and whether they were kept, to an
+// object per snapshot so that they can be inspected later. They are not loaded into BigQuery.
+func writeRejectsToStorage(ctx context.Context, bkt *storage.BucketHandle, flavor wowapiclient.GameFlavor, snapshotTime time.Time, rejects wowapiclient.Quarantine) (string, error) {
+	rows := make([][]string, 0, len(rejects))
+	for _, r := range rejects {
+		a := r.Auction
+		rows = append(rows, []string{
+			strconv.Itoa(a.ID),
+			strconv.Itoa(a.ItemID),
+			strconv.Itoa(a.Quantity),
+			strconv.Itoa(a.UnitPrice),
+			strconv.Itoa(a.Buyout),
+			strconv.Itoa(a.Bid),
+			string(a.TimeLeft),
+			strconv.Itoa(a.RealmID),
+			string(a.Faction),
+			strconv.Itoa(a.AuctionHouseID),
+			r.Reason,
+			strconv.FormatBool(r.Kept),
+		})
 	}
 
-	return nil
+	name := fmt.Sprintf("%s/%s.csv", flavorObjectName(_rejectsFileName, flavor), cloudfunc.FormatTime(snapshotTime))
+	return cloudfunc.WriteCSV(ctx, bkt, name, rows)
 }
 
-func fetchSecret(ctx context.Context, secretClient *secretmanager.Client, name string) (string, error) {
-	fetchCtx, cancel := context.WithTimeout(ctx, _secretFetchTimeout)
-	defer cancel()
+func writeLifecyclesToStorage(ctx context.Context, bkt *storage.BucketHandle, flavor wowapiclient.GameFlavor, lifecycles []lifecycle.Lifecycle) (string, error) {
+	rows := make([][]string, 0, len(lifecycles))
+	for _, l := range lifecycles {
+		row := []string{
+			strconv.Itoa(l.RealmID),
+			strconv.Itoa(l.AuctionID),
+			strconv.Itoa(l.ItemID),
+			cloudfunc.FormatTime(l.FirstSeen),
+			cloudfunc.FormatTime(l.LastSeen),
+			cloudfunc.FormatTime(l.EndedAt),
+			strconv.FormatBool(l.SeenAtListing),
+			strconv.Itoa(l.ListingUnitPrice),
+			strconv.Itoa(l.ListingQuantity),
+			strconv.Itoa(l.SoldQuantity),
+			string(l.Disposition),
+		}
+		for _, tl := range []wowapiclient.TimeLeft{
+			wowapiclient.TimeLeftVeryLong,
+			wowapiclient.TimeLeftLong,
+			wowapiclient.TimeLeftMedium,
+			wowapiclient.TimeLeftShort,
+		} {
+			t, _ := l.ObservedAt(tl)
+			row = append(row, cloudfunc.FormatTime(t))
+		}
+		row = append(row, string(flavor))
+		rows = append(rows, row)
+	}
+
+	return cloudfunc.WriteCSV(ctx, bkt, flavorObjectName(_lifecyclesFileName, flavor), rows)
+}
 
-	secret, err := secretClient.AccessSecretVersion(fetchCtx, &secretmanagerpb.AccessSecretVersionRequest{
-		Name: name,
-	})
+// newAPIClient creates an API client whose oauth2 tokens are shared by every instance of the cloud
+// functions.
+func newAPIClient(ctx context.Context, credentials []wowapiclient.OAuth2Secrets, flavor wowapiclient.GameFlavor, client *storage.Client) (*wowapiclient.WOWAPIClient, error) {
+	httpClient, err := cloudfunc.NewAPIHTTPClient(ctx, credentials, _region, client)
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to fetch %q", name)
+		log.Printf("failed to get oauth2 http client %v", err)
+		return nil, err
 	}
 
-	payload := secret.GetPayload()
-	if payload == nil {
-		return "", fmt.Errorf("sercet %q returned an empty payload", name)
-	}
+	return wowapiclient.NewWOWAPIClient(httpClient, _region, wowapiclient.WithFlavor(flavor)), nil
+}
 
-	data := payload.GetData()
-	if len(data) == 0 {
-		return "", fmt.Errorf("secret %q returned empty data", name)
+// setDefaults fetches retail Zul'jin auctions unless told otherwise. There is no default connected
+// realm for classic flavors.
+func (m *PubSubMessage) setDefaults() error {
+	if m.Flavor == "" {
+		m.Flavor = wowapiclient.FlavorRetail
 	}
-
-	return string(data), nil
+	if m.ConnectedRealmID == 0 {
+		if m.Flavor != wowapiclient.FlavorRetail {
+			return fmt.Errorf("connectedRealmID is required for %v auctions", m.Flavor)
+		}
+		m.ConnectedRealmID = _zuljinID
+	}
+	return nil
 }
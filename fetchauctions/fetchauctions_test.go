@@ -0,0 +1,95 @@
+package fetchrealms
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+)
+
+func TestFetchAuctions(t *testing.T) {
+	s := wowapitest.NewServer(wowapitest.Fixtures{
+		ConnectedRealms: []wowapiclient.ConnectedRealm{{ID: _zuljinID}},
+		Auctions: map[int][]wowapiclient.Auction{
+			_zuljinID: {
+				{ID: 1, ItemID: 19019, Quantity: 1, Buyout: 5000000, TimeLeft: wowapiclient.TimeLeftVeryLong},
+				{ID: 2, ItemID: 2589, Quantity: 20, Buyout: 3000, TimeLeft: wowapiclient.TimeLeftLong},
+				{ID: 3, ItemID: 2589, Quantity: 0, Buyout: 3000, TimeLeft: wowapiclient.TimeLeftLong},
+			},
+		},
+	})
+	defer s.Close()
+
+	apiClient, err := s.NewClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	auctions, rejects, err := fetchAuctions(apiClient, _zuljinID)
+	if err != nil {
+		t.Fatalf("fetchAuctions() error = %v", err)
+	}
+	if len(auctions) != 2 {
+		t.Fatalf("fetchAuctions() got %v auctions, want 2", len(auctions))
+	}
+	if got := auctions[1].EffectiveUnitPrice(); got != 150 {
+		t.Errorf("EffectiveUnitPrice() = %v, want 150", got)
+	}
+	if len(rejects) != 1 || rejects[0].Auction.ID != 3 {
+		t.Errorf("fetchAuctions() rejected %+v, want auction 3", rejects)
+	}
+
+	s.AddFault("", wowapitest.Fault{StatusCode: http.StatusServiceUnavailable, Count: 1})
+	if _, _, err := fetchAuctions(apiClient, _zuljinID); err == nil {
+		t.Error("fetchAuctions() of an unavailable realm error = nil")
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     PubSubMessage
+		want    PubSubMessage
+		wantErr bool
+	}{
+		{
+			name: "retail zuljin by default",
+			msg:  PubSubMessage{Target: _targetName},
+			want: PubSubMessage{Target: _targetName, Flavor: wowapiclient.FlavorRetail, ConnectedRealmID: _zuljinID},
+		},
+		{
+			name: "classic with a connected realm",
+			msg:  PubSubMessage{Flavor: wowapiclient.FlavorClassicEra, ConnectedRealmID: 5001},
+			want: PubSubMessage{Flavor: wowapiclient.FlavorClassicEra, ConnectedRealmID: 5001},
+		},
+		{
+			name:    "classic without a connected realm",
+			msg:     PubSubMessage{Flavor: wowapiclient.FlavorClassicEra},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.msg
+			err := msg.setDefaults()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setDefaults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && msg != tt.want {
+				t.Errorf("setDefaults() = %+v, want %+v", msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlavorObjectName(t *testing.T) {
+	if got := flavorObjectName("auctions", wowapiclient.FlavorRetail); got != "auctions" {
+		t.Errorf("flavorObjectName(retail) = %q, want auctions", got)
+	}
+	if got := flavorObjectName("auctions", wowapiclient.FlavorClassicEra); got != "auctions_classic_era" {
+		t.Errorf("flavorObjectName(classic_era) = %q, want auctions_classic_era", got)
+	}
+}
@@ -3,10 +3,10 @@ module github.com/ZymoticB/wowauctiondata/fetchrealms
 go 1.13
 
 require (
-	cloud.google.com/go v0.56.0
 	cloud.google.com/go/pubsub v1.2.0
 	cloud.google.com/go/storage v1.6.0
 	github.com/ZymoticB/wowauctiondata v0.0.6-0.20200421162104-cc6f1561eff8
 	github.com/pkg/errors v0.9.1
-	google.golang.org/genproto v0.0.0-20200413115906-b5235f65be36
 )
+
+replace github.com/ZymoticB/wowauctiondata => ../
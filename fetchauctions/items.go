@@ -0,0 +1,153 @@
+package fetchrealms
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/blobstore/gcs"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/ZymoticB/wowauctiondata/iconcache"
+	"github.com/ZymoticB/wowauctiondata/itemcache"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_itemCacheName = "item_cache.json"
+	_itemsFileName = "items"
+
+	// _maxItemLookups bounds how many items are looked up per invocation, anything left over is
+	// looked up by later invocations.
+	_maxItemLookups = 1000
+)
+
+// _outputLocales are the locales item names are written in, in addition to the default name.
+var _outputLocales = []wowapiclient.Locale{
+	wowapiclient.LocaleEnUS,
+	wowapiclient.LocaleEsMX,
+	wowapiclient.LocalePtBR,
+}
+
+// _itemCacheDir keeps the item cache in a local directory rather than the bucket, for offline runs.
+var _itemCacheDir = os.Getenv("ITEM_CACHE_DIR")
+
+// _cacheItemIcons copies the icons of newly looked up items into the item cache store when set.
+var _cacheItemIcons = os.Getenv("CACHE_ITEM_ICONS") != ""
+
+// enrichItems looks up any items in auctions which are missing from the item cache and rewrites the
+// items dimension table.
+func enrichItems(ctx context.Context, apiClient *wowapiclient.WOWAPIClient, bkt *storage.BucketHandle, auctions []wowapiclient.Auction) error {
+	var store blobstore.Store = gcs.NewStore(bkt)
+	if _itemCacheDir != "" {
+		store = blobstore.Dir(_itemCacheDir)
+	}
+
+	if itemCache == nil {
+		c := itemcache.New()
+		if err := c.Load(ctx, store, _itemCacheName); err != nil {
+			return err
+		}
+		itemCache = c
+	}
+
+	ids := make([]int, 0, len(auctions))
+	for _, a := range auctions {
+		ids = append(ids, a.ItemID)
+	}
+	stale := itemCache.Stale(ids)
+	if len(stale) == 0 {
+		log.Printf("all %v items are cached", len(ids))
+		return nil
+	}
+	if len(stale) > _maxItemLookups {
+		log.Printf("deferring lookup of %v items", len(stale)-_maxItemLookups)
+		stale = stale[:_maxItemLookups]
+	}
+
+	// save whatever was resolved even if a lookup failed part way through
+	resolveErr := itemCache.Resolve(stale, func(id int) (wowapiclient.Item, error) {
+		return apiClient.GetItem(id, wowapiclient.WithLocale(wowapiclient.LocaleAll))
+	})
+	if err := itemCache.Save(ctx, store, _itemCacheName); err != nil {
+		return err
+	}
+	if resolveErr != nil {
+		return resolveErr
+	}
+
+	if _cacheItemIcons {
+		cacheIcons(ctx, apiClient, store, stale)
+	}
+
+	gcsRef, err := writeItemsToStorage(ctx, bkt, itemCache.Items())
+	if err != nil {
+		return err
+	}
+
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _itemsTableID, cloudfunc.WriteTruncate); err != nil {
+		return errors.Wrap(err, "failed to notify storagetobigquery")
+	}
+
+	log.Printf("looked up %v items", len(stale))
+	return nil
+}
+
+// cacheIcons downloads the icon of every item in ids which exists, and retries items whose icon failed
+// to download earlier. Icons are only cosmetic, so a failure is logged and the remaining items are
+// still downloaded.
+func cacheIcons(ctx context.Context, apiClient *wowapiclient.WOWAPIClient, store blobstore.Store, ids []int) {
+	downloader := iconcache.NewDownloader(store, http.DefaultClient)
+	due, err := downloader.Due(ctx)
+	if err != nil {
+		log.Printf("failed to get icons due a retry: %v", err)
+	}
+	if len(due) > 0 {
+		log.Printf("retrying the icons of %v items", len(due))
+	}
+	for _, id := range append(due, ids...) {
+		if _, ok := itemCache.Get(id); !ok {
+			continue
+		}
+
+		media, err := apiClient.GetItemMedia(id)
+		if err != nil {
+			log.Printf("failed to get media of item %v: %v", id, err)
+			if err := downloader.Failed(ctx, id); err != nil {
+				log.Printf("failed to record the icon failure of item %v: %v", id, err)
+			}
+			continue
+		}
+		if _, ok := media.Icon(); !ok {
+			log.Printf("item %v has no icon", id)
+			continue
+		}
+		if _, err := downloader.Fetch(ctx, media); err != nil {
+			log.Printf("failed to cache the icon of item %v: %v", id, err)
+		}
+	}
+}
+
+func writeItemsToStorage(ctx context.Context, bkt *storage.BucketHandle, items []wowapiclient.Item) (string, error) {
+	rows := make([][]string, 0, len(items))
+	for _, i := range items {
+		row := []string{
+			strconv.Itoa(i.ID),
+			i.Name.String(),
+			i.ItemClass,
+			strconv.Itoa(i.ItemClassID),
+			i.ItemSubclass,
+			strconv.Itoa(i.ItemSubclassID),
+		}
+		for _, l := range _outputLocales {
+			row = append(row, i.Name.Get(l))
+		}
+		rows = append(rows, row)
+	}
+
+	return cloudfunc.WriteCSV(ctx, bkt, _itemsFileName, rows)
+}
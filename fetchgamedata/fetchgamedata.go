@@ -0,0 +1,91 @@
+package fetchgamedata
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/pkg/errors"
+)
+
+const (
+	_destBucketName = "wow-realm-data"
+
+	_region = "us"
+)
+
+var _projectID = os.Getenv("GCP_PROJECT")
+
+// target fetches one kind of game data and loads it into BigQuery.
+type target func(ctx context.Context, httpClient *http.Client, bkt *storage.BucketHandle) error
+
+// _targets are the targets handled by FetchGameData, keyed by target name.
+var _targets = map[string]target{
+	_itemClassesTargetName: fetchItemClasses,
+	_recipesTargetName:     fetchRecipes,
+	_tokenPricesTargetName: fetchTokenPrices,
+}
+
+// PubSubContainer is a container for the inbound pubsub message which is provided in
+// Data.
+type PubSubContainer struct {
+	Data []byte `json:"data"`
+}
+
+// PubSubMessage is the decoded pub/sub message sent to the application
+type PubSubMessage struct {
+	Target string `json:"target"`
+}
+
+// publisher notifies storagetobigtable, it is kept for the life of the instance.
+var publisher = cloudfunc.NewPublisher(_projectID)
+
+// FetchGameData is a cloud function to fetch wow game data such as item classes, recipes and token prices
+func FetchGameData(ctx context.Context, m PubSubContainer) error {
+	if len(m.Data) == 0 {
+		log.Println("got empty message, skipping")
+		return nil
+	}
+
+	msg := PubSubMessage{}
+	err := cloudfunc.DecodeMessage(m.Data, &msg)
+	if err != nil {
+		log.Printf("failed to get message from pub/sub message: %v", err)
+		return err
+	}
+
+	fetch, ok := _targets[msg.Target]
+	if !ok {
+		log.Printf("trigger intended for a different target %q", msg.Target)
+		return nil
+	}
+
+	credentials, err := cloudfunc.FetchAPICredentials(ctx)
+	if err != nil {
+		log.Printf("failed to fetch secrets: %v", err)
+		return err
+	}
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gcp client")
+	}
+	defer client.Close()
+
+	httpClient, err := cloudfunc.NewAPIHTTPClient(ctx, credentials, _region, client)
+	if err != nil {
+		log.Printf("failed to get oauth2 http client %v", err)
+		return err
+	}
+
+	if err := fetch(ctx, httpClient, client.Bucket(_destBucketName)); err != nil {
+		log.Printf("failed to fetch %v: %v", msg.Target, err)
+		return err
+	}
+
+	log.Printf("successfully fetched %v", msg.Target)
+	return nil
+}
@@ -0,0 +1,12 @@
+module github.com/ZymoticB/wowauctiondata/fetchgamedata
+
+go 1.13
+
+require (
+	cloud.google.com/go/pubsub v1.2.0
+	cloud.google.com/go/storage v1.6.0
+	github.com/ZymoticB/wowauctiondata v0.0.5
+	github.com/pkg/errors v0.9.1
+)
+
+replace github.com/ZymoticB/wowauctiondata => ../
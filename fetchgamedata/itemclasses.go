@@ -0,0 +1,63 @@
+package fetchgamedata
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_itemClassesTargetName = "fetch-item-classes"
+	_itemClassesFileName   = "item_classes"
+	_itemClassesTableID    = "item_classes"
+)
+
+// fetchItemClasses rewrites the item_classes dimension table with one row per item subclass.
+func fetchItemClasses(ctx context.Context, httpClient *http.Client, bkt *storage.BucketHandle) error {
+	apiClient := wowapiclient.NewWOWAPIClient(httpClient, _region)
+	classes, err := apiClient.GetItemClasses()
+	if err != nil {
+		return errors.Wrap(err, "failed to get item classes")
+	}
+
+	var rows [][]string
+	for _, c := range classes {
+		ic, err := apiClient.GetItemClass(c.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get item class %v", c.ID)
+		}
+
+		for _, sc := range ic.Subclasses {
+			subclass, err := apiClient.GetItemSubclass(ic.ID, sc.ID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get item subclass %v of class %v", sc.ID, ic.ID)
+			}
+
+			rows = append(rows, []string{
+				strconv.Itoa(ic.ID),
+				ic.Name.String(),
+				strconv.Itoa(subclass.ID),
+				subclass.Name.String(),
+				subclass.VerboseName.String(),
+			})
+		}
+	}
+
+	gcsRef, err := cloudfunc.WriteCSV(ctx, bkt, _itemClassesFileName, rows)
+	if err != nil {
+		return err
+	}
+
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _itemClassesTableID, cloudfunc.WriteTruncate); err != nil {
+		return errors.Wrap(err, "failed to notify storagetobigquery")
+	}
+
+	log.Printf("wrote %v item subclasses", len(rows))
+	return nil
+}
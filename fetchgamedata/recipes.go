@@ -0,0 +1,161 @@
+package fetchgamedata
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_recipesTargetName = "fetch-recipes"
+	_recipesFileName   = "recipes"
+	_recipesTableID    = "recipes"
+
+	// _recipeWorkers is how many recipes are fetched at once, there are thousands of them.
+	_recipeWorkers = 8
+)
+
+// recipeSource is where in the profession tree a recipe was found.
+type recipeSource struct {
+	profession wowapiclient.Profession
+	skillTier  wowapiclient.SkillTier
+	recipe     wowapiclient.RecipeRef
+}
+
+// fetchRecipes rewrites the recipes dimension table with one row per recipe reagent.
+func fetchRecipes(ctx context.Context, httpClient *http.Client, bkt *storage.BucketHandle) error {
+	apiClient := wowapiclient.NewWOWAPIClient(httpClient, _region)
+	sources, err := listRecipes(apiClient)
+	if err != nil {
+		return err
+	}
+
+	recipes, err := getRecipes(apiClient, sources)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for i, src := range sources {
+		r := recipes[i]
+
+		row := []string{
+			strconv.Itoa(r.ID),
+			r.Name.String(),
+			strconv.Itoa(src.profession.ID),
+			src.profession.Name.String(),
+			strconv.Itoa(src.skillTier.ID),
+			src.skillTier.Name.String(),
+			strconv.Itoa(r.CraftedItemID),
+			strconv.Itoa(r.AllianceCraftedItemID),
+			strconv.Itoa(r.HordeCraftedItemID),
+			strconv.FormatFloat(r.CraftedQuantity, 'f', -1, 64),
+		}
+		if len(r.Reagents) == 0 {
+			rows = append(rows, append(row, "", ""))
+			continue
+		}
+		for _, rg := range r.Reagents {
+			rows = append(rows, append(append([]string(nil), row...), strconv.Itoa(rg.ItemID), strconv.Itoa(rg.Quantity)))
+		}
+	}
+
+	gcsRef, err := cloudfunc.WriteCSV(ctx, bkt, _recipesFileName, rows)
+	if err != nil {
+		return err
+	}
+
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _recipesTableID, cloudfunc.WriteTruncate); err != nil {
+		return errors.Wrap(err, "failed to notify storagetobigquery")
+	}
+
+	log.Printf("wrote %v recipes", len(sources))
+	return nil
+}
+
+// getRecipes gets the recipe of every source with _recipeWorkers workers. Nothing more is dispatched
+// once a recipe fails, and the first failure is returned.
+func getRecipes(apiClient *wowapiclient.WOWAPIClient, sources []recipeSource) ([]wowapiclient.Recipe, error) {
+	recipes := make([]wowapiclient.Recipe, len(sources))
+	indexes := make(chan int)
+	done := make(chan struct{})
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+	for w := 0; w < _recipeWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				r, err := apiClient.GetRecipe(sources[i].recipe.ID)
+				if err != nil {
+					failOnce.Do(func() {
+						firstErr = errors.Wrapf(err, "failed to get recipe %v", sources[i].recipe.ID)
+						close(done)
+					})
+					continue
+				}
+				recipes[i] = r
+			}
+		}()
+	}
+
+dispatch:
+	for i := range sources {
+		select {
+		case indexes <- i:
+		case <-done:
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return recipes, nil
+}
+
+// listRecipes walks every profession and skill tier to find every recipe.
+func listRecipes(apiClient *wowapiclient.WOWAPIClient) ([]recipeSource, error) {
+	professions, err := apiClient.GetProfessions()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get professions")
+	}
+
+	var sources []recipeSource
+	for _, p := range professions {
+		profession, err := apiClient.GetProfession(p.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get profession %v", p.ID)
+		}
+
+		for _, st := range profession.SkillTiers {
+			tier, err := apiClient.GetProfessionSkillTier(profession.ID, st.ID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get skill tier %v of profession %v", st.ID, profession.ID)
+			}
+
+			for _, cat := range tier.Categories {
+				for _, r := range cat.Recipes {
+					sources = append(sources, recipeSource{
+						profession: profession,
+						skillTier:  tier,
+						recipe:     r,
+					})
+				}
+			}
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,57 @@
+package fetchgamedata
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_tokenPricesTargetName = "fetch-token-prices"
+	_tokenPricesFileName   = "token_prices"
+	_tokenPricesTableID    = "token_prices"
+)
+
+// _tokenRegions are the regions whose token price is tracked. A battle.net token from any of these
+// regions is accepted by all of them.
+var _tokenRegions = []string{"us", "eu", "kr", "tw"}
+
+// fetchTokenPrices appends the current token price of every region to the token_prices table.
+func fetchTokenPrices(ctx context.Context, httpClient *http.Client, bkt *storage.BucketHandle) error {
+	fetchedAt := time.Now()
+
+	rows := make([][]string, 0, len(_tokenRegions))
+	for _, region := range _tokenRegions {
+		apiClient := wowapiclient.NewWOWAPIClient(httpClient, region)
+		price, err := apiClient.GetTokenPrice()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get %v token price", region)
+		}
+
+		rows = append(rows, []string{
+			price.Region,
+			strconv.Itoa(price.Price),
+			cloudfunc.FormatTime(price.LastUpdated),
+			cloudfunc.FormatTime(fetchedAt),
+		})
+	}
+
+	gcsRef, err := cloudfunc.WriteCSV(ctx, bkt, _tokenPricesFileName, rows)
+	if err != nil {
+		return err
+	}
+
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _tokenPricesTableID, cloudfunc.WriteAppend); err != nil {
+		return errors.Wrap(err, "failed to notify storagetobigquery")
+	}
+
+	log.Printf("wrote %v token prices", len(rows))
+	return nil
+}
@@ -1,43 +1,45 @@
 package fetchrealms
 
 import (
-	"bytes"
 	"context"
-	"encoding/csv"
-	"encoding/json"
-	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
-	"time"
 
-	"cloud.google.com/go/pubsub"
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
 	"github.com/ZymoticB/wowauctiondata/wowapiclient"
 	"github.com/pkg/errors"
-	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
 )
 
 const (
-	_targetName         = "fetch-realms"
-	_secretFetchTimeout = 5 * time.Second
-
-	_clientIDSecretName     = "projects/13595582905/secrets/blizzard-oauth-client-id/versions/latest"
-	_clientSecretSecretName = "projects/13595582905/secrets/blizzard-oauth-client-secret/versions/latest"
+	_targetName = "fetch-realms"
 
 	_destBucketName = "wow-realm-data"
 	_destFileName   = "realms"
 
 	_region = "us"
 
-	_datasetID     = "wow_data"
-	_tableID       = "connected_realms"
-	_writeTruncate = "truncate"
+	_tableID = "connected_realms"
 )
 
 var _projectID = os.Getenv("GCP_PROJECT")
 
+// _flavors are the game flavors whose realms are fetched.
+var _flavors = []wowapiclient.GameFlavor{
+	wowapiclient.FlavorRetail,
+	wowapiclient.FlavorClassic,
+	wowapiclient.FlavorClassicEra,
+}
+
+// _outputLocales are the locales realm names are written in, in addition to the default name.
+var _outputLocales = []wowapiclient.Locale{
+	wowapiclient.LocaleEnUS,
+	wowapiclient.LocaleEsMX,
+	wowapiclient.LocalePtBR,
+}
+
 // PubSubContainer is a container for the inbound pubsub message which is provided in
 // Data.
 type PubSubContainer struct {
@@ -49,20 +51,8 @@ type PubSubMessage struct {
 	Target string `json:"target"`
 }
 
-// pubsubClient is a global Pub/Sub client, initialized once per instance.
-var pubsubClient *pubsub.Client
-
-func init() {
-	// err is pre-declared to avoid shadowing client.
-	var err error
-
-	// client is initialized with context.Background() because it should
-	// persist between function invocations.
-	pubsubClient, err = pubsub.NewClient(context.Background(), _projectID)
-	if err != nil {
-		log.Fatalf("pubsub.NewClient: %v", err)
-	}
-}
+// publisher notifies storagetobigtable, it is kept for the life of the instance.
+var publisher = cloudfunc.NewPublisher(_projectID)
 
 // FetchRealms is a cloud function to fetch all wow realms
 func FetchRealms(ctx context.Context, m PubSubContainer) error {
@@ -71,7 +61,8 @@ func FetchRealms(ctx context.Context, m PubSubContainer) error {
 		return nil
 	}
 
-	msg, err := getMessage(m)
+	msg := PubSubMessage{}
+	err := cloudfunc.DecodeMessage(m.Data, &msg)
 	if err != nil {
 		log.Printf("failed to get message from pub/sub message: %v", err)
 		return err
@@ -82,168 +73,104 @@ func FetchRealms(ctx context.Context, m PubSubContainer) error {
 		return nil
 	}
 
-	secrets := map[string]string{
-		_clientIDSecretName:     "",
-		_clientSecretSecretName: "",
+	credentials, err := cloudfunc.FetchAPICredentials(ctx)
+	if err != nil {
+		log.Printf("failed to fetch secrets: %v", err)
+		return err
 	}
 
-	err = fetchSecrets(ctx, secrets)
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gcp client")
+	}
+	defer client.Close()
+	bkt := client.Bucket(_destBucketName)
+
+	httpClient, err := newHTTPClient(ctx, credentials, client)
 	if err != nil {
-		log.Printf("failed to fetch secrets: %v", err)
 		return err
 	}
 
-	realms, err := fetchRealms(ctx, secrets)
+	realms, err := fetchRealms(httpClient)
 	if err != nil {
 		log.Printf("failed to fetch realms: %v", err)
 		return err
 	}
-	log.Printf("Got realms %v", realms)
+	for flavor, crs := range realms {
+		log.Printf("Got %v %v connected realms", crs.Len(), flavor)
+	}
 
-	gcsRef, err := writeRealmsToStorage(ctx, realms)
+	gcsRef, err := writeRealmsToStorage(ctx, bkt, realms)
 	if err != nil {
 		log.Printf("failed to write to storage: %v", err)
 		return err
 	}
 
-	if err := notifyStorageToBigQuery(ctx, gcsRef); err != nil {
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _tableID, cloudfunc.WriteTruncate); err != nil {
 		return errors.Wrap(err, "failed to notify storagetobigquery")
 	}
 
 	log.Printf("successfully wrote realms to storage")
-	return nil
-}
-
-func writeRealmsToStorage(ctx context.Context, realms wowapiclient.ConnectedRealms) (string, error) {
-	client, err := storage.NewClient(ctx)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to create gcp client")
-	}
 
-	bkt := client.Bucket(_destBucketName)
-	obj := bkt.Object(_destFileName)
-	writer := obj.NewWriter(ctx)
-	csvWriter := csv.NewWriter(writer)
-	for name, cr := range realms {
-		err := csvWriter.Write([]string{name, strconv.Itoa(cr.ID)})
-		if err != nil {
-			return "", errors.Wrap(err, "failed to write to storage")
-		}
-	}
-
-	csvWriter.Flush()
-	if err := csvWriter.Error(); err != nil {
-		return "", errors.Wrap(err, "failed to write to storage")
-	}
-
-	if err := writer.Close(); err != nil {
-		return "", errors.Wrap(err, "failed to write to storage")
+	if err := trackRealmMerges(ctx, httpClient, bkt); err != nil {
+		log.Printf("failed to track realm merges: %v", err)
+		return err
 	}
-
-	return fmt.Sprintf("gs://%s/%s", _destBucketName, _destFileName), nil
-}
-
-// TODO move this to a shared module
-type pubSubMessage struct {
-	// expected to be of the form gs://...
-	GCSReference string `json:"gcsReference"`
-	DatasetID    string `json:"datasetID"`
-	TableID      string `json:"tableID"`
-	WriteMode    string `json:"writeMode"`
+	return nil
 }
 
-func notifyStorageToBigQuery(ctx context.Context, gcsRef string) error {
-	msg := pubSubMessage{
-		GCSReference: gcsRef,
-		DatasetID:    _datasetID,
-		TableID:      _tableID,
-		WriteMode:    _writeTruncate,
-	}
-	b, err := json.Marshal(msg)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal pubsub message")
-	}
-
-	t := pubsubClient.Topic("storagetobigtable")
-	_, err = t.Publish(ctx, &pubsub.Message{
-		Data: b,
-	}).Get(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to publish message to pubsub")
+func writeRealmsToStorage(ctx context.Context, bkt *storage.BucketHandle, realms map[wowapiclient.GameFlavor]wowapiclient.ConnectedRealms) (string, error) {
+	var rows [][]string
+	for _, flavor := range _flavors {
+		for _, cr := range realms[flavor].All() {
+			for _, r := range cr.Realms {
+				row := []string{r.Name.String(), strconv.Itoa(cr.ID)}
+				for _, l := range _outputLocales {
+					row = append(row, r.Name.Get(l))
+				}
+				row = append(row,
+					string(flavor),
+					strconv.Itoa(r.ID),
+					r.Slug,
+					r.Category.String(),
+					r.Locale,
+					r.Timezone,
+					string(r.Type),
+					strconv.FormatBool(r.IsTournament),
+					strconv.FormatBool(cr.HasQueue),
+					string(cr.Status),
+					string(cr.Population),
+				)
+				rows = append(rows, row)
+			}
+		}
 	}
 
-	log.Printf("wrote message to storagetobigtable")
-	return nil
+	return cloudfunc.WriteCSV(ctx, bkt, _destFileName, rows)
 }
 
-func fetchRealms(ctx context.Context, secrets map[string]string) (wowapiclient.ConnectedRealms, error) {
-	httpClient, err := wowapiclient.GetHTTPClient(ctx, wowapiclient.OAuth2Secrets{
-		ClientID:     secrets[_clientIDSecretName],
-		ClientSecret: secrets[_clientSecretSecretName],
-	}, _region)
+// newHTTPClient creates an HTTP client whose oauth2 tokens are shared by every instance of the cloud
+// functions.
+func newHTTPClient(ctx context.Context, credentials []wowapiclient.OAuth2Secrets, client *storage.Client) (*http.Client, error) {
+	httpClient, err := cloudfunc.NewAPIHTTPClient(ctx, credentials, _region, client)
 	if err != nil {
 		log.Printf("failed to get oauth2 http client %v", err)
 		return nil, err
 	}
-
-	apiClient := wowapiclient.NewWOWAPIClient(httpClient, _region)
-
-	return apiClient.GetConnectedRealms()
+	return httpClient, nil
 }
 
-func getMessage(m PubSubContainer) (PubSubMessage, error) {
-	msg := PubSubMessage{}
-
-	decoder := json.NewDecoder(bytes.NewReader(m.Data))
-	err := decoder.Decode(&msg)
-	if err != nil {
-		return PubSubMessage{}, errors.Wrapf(err, "failed to decode json %q", string(m.Data))
-	}
-	return msg, nil
-}
+func fetchRealms(httpClient *http.Client) (map[wowapiclient.GameFlavor]wowapiclient.ConnectedRealms, error) {
+	realms := make(map[wowapiclient.GameFlavor]wowapiclient.ConnectedRealms, len(_flavors))
+	for _, flavor := range _flavors {
+		apiClient := wowapiclient.NewWOWAPIClient(httpClient, _region, wowapiclient.WithFlavor(flavor))
 
-// fetchSecrets fetches secrets from the secretmanager API using the keys of toFetch as the
-// secret names. It mutates the given toFetch map.
-func fetchSecrets(ctx context.Context, toFetch map[string]string) error {
-	secretClient, err := secretmanager.NewClient(ctx)
-	defer secretClient.Close()
-
-	if err != nil {
-		return err
-	}
-
-	for name := range toFetch {
-		secret, err := fetchSecret(ctx, secretClient, name)
+		crs, err := apiClient.GetConnectedRealms(wowapiclient.WithLocale(wowapiclient.LocaleAll))
 		if err != nil {
-			return errors.Wrapf(err, "failed to fetch secret for %q", name)
+			return nil, errors.Wrapf(err, "failed to get %v connected realms", flavor)
 		}
-
-		toFetch[name] = secret
-	}
-
-	return nil
-}
-
-func fetchSecret(ctx context.Context, secretClient *secretmanager.Client, name string) (string, error) {
-	fetchCtx, cancel := context.WithTimeout(ctx, _secretFetchTimeout)
-	defer cancel()
-
-	secret, err := secretClient.AccessSecretVersion(fetchCtx, &secretmanagerpb.AccessSecretVersionRequest{
-		Name: name,
-	})
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to fetch %q", name)
-	}
-
-	payload := secret.GetPayload()
-	if payload == nil {
-		return "", fmt.Errorf("sercet %q returned an empty payload", name)
-	}
-
-	data := payload.GetData()
-	if len(data) == 0 {
-		return "", fmt.Errorf("secret %q returned empty data", name)
+		realms[flavor] = crs
 	}
 
-	return string(data), nil
+	return realms, nil
 }
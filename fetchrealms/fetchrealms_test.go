@@ -0,0 +1,49 @@
+package fetchrealms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+)
+
+func TestFetchRealms(t *testing.T) {
+	s := wowapitest.NewServer(wowapitest.Fixtures{
+		ConnectedRealms: []wowapiclient.ConnectedRealm{{
+			ID:         61,
+			Status:     wowapiclient.RealmStatusUp,
+			Population: wowapiclient.RealmPopulationHigh,
+			Realms: []wowapiclient.Realm{{
+				ID:   61,
+				Name: wowapiclient.LocalizedString{wowapiclient.LocaleEnUS: "Zul'jin", wowapiclient.LocaleEsMX: "Zul'jin"},
+				Slug: "zuljin",
+				Type: wowapiclient.RealmTypeNormal,
+			}},
+		}},
+	})
+	defer s.Close()
+
+	// fetchRealms creates its own clients for the real API host, which the fake server's transport
+	// redirects to the fake server
+	httpClient, err := s.HTTPClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to create http client: %v", err)
+	}
+
+	realms, err := fetchRealms(httpClient)
+	if err != nil {
+		t.Fatalf("fetchRealms() error = %v", err)
+	}
+	if len(realms) != len(_flavors) {
+		t.Fatalf("fetchRealms() got %v flavors, want %v", len(realms), len(_flavors))
+	}
+
+	cr, err := realms[wowapiclient.FlavorRetail].Get("zuljin")
+	if err != nil {
+		t.Fatalf("Get(zuljin) error = %v", err)
+	}
+	if got := cr.Realms[0].Name.Get(wowapiclient.LocaleEsMX); got != "Zul'jin" {
+		t.Errorf("es_MX realm name = %q, want Zul'jin", got)
+	}
+}
@@ -0,0 +1,133 @@
+package fetchrealms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/blobstore/gcs"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_realmMapNameFormat = "realm_map_%s.json"
+
+	// _realmsFileName is apart from _destFileName, which FetchRealms loads into the connected realms
+	// table asynchronously.
+	_realmsFileName = "realm_names"
+	_mergesFileName = "realm_merges"
+
+	_realmsTableID = "realms"
+	_mergesTableID = "realm_merges"
+)
+
+// trackRealmMerges fetches every realm, rewrites the realms table and records any realms which moved to a
+// different connected realm since the last run.
+func trackRealmMerges(ctx context.Context, httpClient *http.Client, bkt *storage.BucketHandle) error {
+	store := gcs.NewStore(bkt)
+	detectedAt := cloudfunc.FormatTime(time.Now())
+
+	var realmRows, mergeRows [][]string
+	maps := make(map[wowapiclient.GameFlavor]wowapiclient.RealmMap, len(_flavors))
+	for _, flavor := range _flavors {
+		apiClient := wowapiclient.NewWOWAPIClient(httpClient, _region, wowapiclient.WithFlavor(flavor))
+		realms, err := apiClient.GetRealms()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get %v realms", flavor)
+		}
+
+		for _, r := range realms {
+			realmRows = append(realmRows, []string{
+				strconv.Itoa(r.ID),
+				r.Slug,
+				r.Name.String(),
+				strconv.Itoa(r.ConnectedRealmID),
+				string(flavor),
+			})
+		}
+
+		cur := wowapiclient.NewRealmMap(realms)
+		maps[flavor] = cur
+		prev, err := loadRealmMap(ctx, store, flavor)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range cur.MergesSince(prev) {
+			log.Printf("%v realm %v moved from connected realm %v to %v", flavor, m.RealmID, m.FromConnectedRealmID, m.ToConnectedRealmID)
+			mergeRows = append(mergeRows, []string{
+				detectedAt,
+				strconv.Itoa(m.RealmID),
+				strconv.Itoa(m.FromConnectedRealmID),
+				strconv.Itoa(m.ToConnectedRealmID),
+				strconv.FormatBool(m.FromConnectedRealmGone),
+				string(flavor),
+			})
+		}
+	}
+
+	gcsRef, err := cloudfunc.WriteCSV(ctx, bkt, _realmsFileName, realmRows)
+	if err != nil {
+		return err
+	}
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _realmsTableID, cloudfunc.WriteTruncate); err != nil {
+		return errors.Wrap(err, "failed to notify storagetobigquery")
+	}
+
+	if len(mergeRows) == 0 {
+		log.Printf("no realm merges detected")
+	} else {
+		gcsRef, err = cloudfunc.WriteCSV(ctx, bkt, _mergesFileName, mergeRows)
+		if err != nil {
+			return err
+		}
+		if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _mergesTableID, cloudfunc.WriteAppend); err != nil {
+			return errors.Wrap(err, "failed to notify storagetobigquery")
+		}
+		log.Printf("recorded %v realm merges", len(mergeRows))
+	}
+
+	// The realm maps are only saved once the merges have been loaded, otherwise a retry would diff
+	// against them and the merges would be lost.
+	for _, flavor := range _flavors {
+		if err := saveRealmMap(ctx, store, flavor, maps[flavor]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadRealmMap(ctx context.Context, store blobstore.Store, flavor wowapiclient.GameFlavor) (wowapiclient.RealmMap, error) {
+	m := wowapiclient.RealmMap{}
+	b, err := store.Get(ctx, fmt.Sprintf(_realmMapNameFormat, flavor))
+	if err == blobstore.ErrNotFound {
+		return m, nil
+	}
+	if err != nil {
+		return m, errors.Wrap(err, "failed to load realm map")
+	}
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, errors.Wrap(err, "failed to decode realm map")
+	}
+	return m, nil
+}
+
+func saveRealmMap(ctx context.Context, store blobstore.Store, flavor wowapiclient.GameFlavor, m wowapiclient.RealmMap) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode realm map")
+	}
+	if err := store.Put(ctx, fmt.Sprintf(_realmMapNameFormat, flavor), b); err != nil {
+		return errors.Wrap(err, "failed to save realm map")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package fetchrealms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/blobstore/gcs"
+	"github.com/ZymoticB/wowauctiondata/cloudfunc"
+	"github.com/ZymoticB/wowauctiondata/realmstatus"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_statusTargetName = "fetch-realm-status"
+
+	_statusSnapshotNameFormat = "realm_status_%s.json"
+
+	_statusFileName = "realm_status"
+	_eventsFileName = "realm_status_events"
+
+	_statusTableID = "realm_status"
+	_eventsTableID = "realm_status_events"
+)
+
+// FetchRealmStatus is a cloud function to record the status of all wow connected realms
+func FetchRealmStatus(ctx context.Context, m PubSubContainer) error {
+	if len(m.Data) == 0 {
+		log.Println("got empty message, skipping")
+		return nil
+	}
+
+	msg := PubSubMessage{}
+	err := cloudfunc.DecodeMessage(m.Data, &msg)
+	if err != nil {
+		log.Printf("failed to get message from pub/sub message: %v", err)
+		return err
+	}
+
+	if msg.Target != _statusTargetName {
+		log.Printf("trigger intended for a different target %q", msg.Target)
+		return nil
+	}
+
+	credentials, err := cloudfunc.FetchAPICredentials(ctx)
+	if err != nil {
+		log.Printf("failed to fetch secrets: %v", err)
+		return err
+	}
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gcp client")
+	}
+	defer client.Close()
+	bkt := client.Bucket(_destBucketName)
+	store := gcs.NewStore(bkt)
+
+	httpClient, err := newHTTPClient(ctx, credentials, client)
+	if err != nil {
+		return err
+	}
+
+	polledAt := time.Now()
+	realms, err := fetchRealms(httpClient)
+	if err != nil {
+		log.Printf("failed to fetch realms: %v", err)
+		return err
+	}
+
+	var statusRows, eventRows [][]string
+	snapshots := make(map[wowapiclient.GameFlavor]realmstatus.Snapshot, len(_flavors))
+	for _, flavor := range _flavors {
+		cur := realmstatus.NewSnapshot(polledAt, realms[flavor])
+		snapshots[flavor] = cur
+		for _, s := range cur.Sorted() {
+			statusRows = append(statusRows, []string{
+				cloudfunc.FormatTime(cur.Time),
+				strconv.Itoa(s.ConnectedRealmID),
+				string(s.Status),
+				strconv.FormatBool(s.HasQueue),
+				string(s.Population),
+				string(flavor),
+			})
+		}
+
+		prev, err := loadStatusSnapshot(ctx, store, flavor)
+		if err != nil {
+			return err
+		}
+		// the first poll has nothing to compare against
+		if !prev.Time.IsZero() {
+			for _, e := range realmstatus.Changes(prev, cur) {
+				log.Printf("%v connected realm %v %v", flavor, e.ConnectedRealmID, e.Type)
+				eventRows = append(eventRows, []string{
+					cloudfunc.FormatTime(e.Time),
+					strconv.Itoa(e.ConnectedRealmID),
+					string(e.Type),
+					e.From,
+					e.To,
+					string(flavor),
+				})
+			}
+		}
+	}
+
+	// The events are written before the status rows, so that a failure writing the events does not
+	// leave status rows behind which a retry would append again.
+	if len(eventRows) == 0 {
+		log.Printf("no realm status changes")
+	} else {
+		gcsRef, err := cloudfunc.WriteCSV(ctx, bkt, _eventsFileName, eventRows)
+		if err != nil {
+			log.Printf("failed to write to storage: %v", err)
+			return err
+		}
+		if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _eventsTableID, cloudfunc.WriteAppend); err != nil {
+			return errors.Wrap(err, "failed to notify storagetobigquery")
+		}
+		log.Printf("successfully wrote %v realm status changes to storage", len(eventRows))
+	}
+
+	gcsRef, err := cloudfunc.WriteCSV(ctx, bkt, _statusFileName, statusRows)
+	if err != nil {
+		log.Printf("failed to write to storage: %v", err)
+		return err
+	}
+	if err := cloudfunc.NotifyStorageToBigQuery(ctx, publisher, gcsRef, _statusTableID, cloudfunc.WriteAppend); err != nil {
+		return errors.Wrap(err, "failed to notify storagetobigquery")
+	}
+
+	// The snapshots are only saved once the events have been loaded, otherwise a retry would diff
+	// against them and the events would be lost.
+	for _, flavor := range _flavors {
+		if err := saveStatusSnapshot(ctx, store, flavor, snapshots[flavor]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadStatusSnapshot(ctx context.Context, store blobstore.Store, flavor wowapiclient.GameFlavor) (realmstatus.Snapshot, error) {
+	s := realmstatus.Snapshot{}
+	b, err := store.Get(ctx, fmt.Sprintf(_statusSnapshotNameFormat, flavor))
+	if err == blobstore.ErrNotFound {
+		return s, nil
+	}
+	if err != nil {
+		return s, errors.Wrap(err, "failed to load realm status")
+	}
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return s, errors.Wrap(err, "failed to decode realm status")
+	}
+	return s, nil
+}
+
+func saveStatusSnapshot(ctx context.Context, store blobstore.Store, flavor wowapiclient.GameFlavor, s realmstatus.Snapshot) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode realm status")
+	}
+	if err := store.Put(ctx, fmt.Sprintf(_statusSnapshotNameFormat, flavor), b); err != nil {
+		return errors.Wrap(err, "failed to save realm status")
+	}
+	return nil
+}
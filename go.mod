@@ -3,6 +3,10 @@ module github.com/ZymoticB/wowauctiondata
 go 1.13
 
 require (
+	cloud.google.com/go v0.56.0
+	cloud.google.com/go/pubsub v1.2.0
+	cloud.google.com/go/storage v1.6.0
 	github.com/pkg/errors v0.9.1
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+	google.golang.org/genproto v0.0.0-20200413115906-b5235f65be36
 )
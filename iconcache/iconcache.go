@@ -0,0 +1,243 @@
+// Package iconcache copies item icons from Blizzard's CDN into a blobstore.Store so they can be served
+// without hotlinking the CDN.
+package iconcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	// _iconNameFormat stores icon contents by hash, many items share an icon.
+	_iconNameFormat = "icons/%s%s"
+	// _manifestNameFormat records which icon an item uses.
+	_manifestNameFormat = "icons/items/%v.json"
+	// _failuresName records when the icon of each item last failed to download.
+	_failuresName = "icons/failures.json"
+
+	_defaultRetryInterval = 6 * time.Hour
+)
+
+// Option configures a Downloader.
+type Option func(*Downloader)
+
+// WithRetryInterval sets how long after a failure the icon of an item is due to be downloaded again.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(d *Downloader) {
+		d.retryInterval = interval
+	}
+}
+
+type manifest struct {
+	SourceURL string `json:"sourceURL"`
+	Name      string `json:"name"`
+}
+
+// Downloader downloads icons into a blobstore.Store. Icon contents are stored once per unique content
+// and each item gets a manifest pointing at its icon. Items whose icon failed to download are recorded
+// so they can be retried. It is not safe for concurrent use.
+type Downloader struct {
+	store         blobstore.Store
+	httpClient    *http.Client
+	retryInterval time.Duration
+	now           func() time.Time
+
+	// failures is loaded on first use.
+	failures map[int]time.Time
+}
+
+// NewDownloader creates a Downloader which stores icons in store. The CDN does not require
+// authentication so httpClient should not be the API client's oauth2 client.
+func NewDownloader(store blobstore.Store, httpClient *http.Client, opts ...Option) *Downloader {
+	d := &Downloader{
+		store:         store,
+		httpClient:    httpClient,
+		retryInterval: _defaultRetryInterval,
+		now:           time.Now,
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// IconName returns the name of the cached icon of an item, or blobstore.ErrNotFound if it has not been
+// downloaded.
+func (d *Downloader) IconName(ctx context.Context, itemID int) (string, error) {
+	m, err := d.manifest(ctx, itemID)
+	if err != nil {
+		return "", err
+	}
+	return m.Name, nil
+}
+
+// Fetch downloads the icon from media unless the item's icon was already downloaded from the same URL,
+// and returns the name of the cached icon. A failure is recorded so that the item is returned by Due
+// once the retry interval has passed.
+func (d *Downloader) Fetch(ctx context.Context, media wowapiclient.ItemMedia) (string, error) {
+	icon, ok := media.Icon()
+	if !ok {
+		return "", fmt.Errorf("item %v has no icon", media.ItemID)
+	}
+
+	name, err := d.fetch(ctx, media.ItemID, icon)
+	if err != nil {
+		if ferr := d.Failed(ctx, media.ItemID); ferr != nil {
+			return "", ferr
+		}
+		return "", err
+	}
+	if err := d.clearFailure(ctx, media.ItemID); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Failed records that the icon of an item could not be cached, for failures such as looking up its
+// media which happen before Fetch.
+func (d *Downloader) Failed(ctx context.Context, itemID int) error {
+	if err := d.loadFailures(ctx); err != nil {
+		return err
+	}
+	d.failures[itemID] = d.now()
+	return d.saveFailures(ctx)
+}
+
+// Due returns the items whose icon failed to download at least the retry interval ago, in ID order.
+func (d *Downloader) Due(ctx context.Context) ([]int, error) {
+	if err := d.loadFailures(ctx); err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for id, failedAt := range d.failures {
+		if d.now().Sub(failedAt) >= d.retryInterval {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (d *Downloader) fetch(ctx context.Context, itemID int, icon wowapiclient.MediaAsset) (string, error) {
+	m, err := d.manifest(ctx, itemID)
+	if err == nil && m.SourceURL == icon.URL {
+		return m.Name, nil
+	}
+	if err != nil && err != blobstore.ErrNotFound {
+		return "", err
+	}
+
+	b, err := d.download(ctx, icon.URL)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	name := fmt.Sprintf(_iconNameFormat, hex.EncodeToString(sum[:]), path.Ext(icon.URL))
+	if err := d.store.Put(ctx, name, b); err != nil {
+		return "", errors.Wrapf(err, "failed to store icon of item %v", itemID)
+	}
+
+	mb, err := json.Marshal(manifest{SourceURL: icon.URL, Name: name})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode icon manifest")
+	}
+	if err := d.store.Put(ctx, fmt.Sprintf(_manifestNameFormat, itemID), mb); err != nil {
+		return "", errors.Wrapf(err, "failed to store icon manifest of item %v", itemID)
+	}
+
+	return name, nil
+}
+
+func (d *Downloader) manifest(ctx context.Context, itemID int) (manifest, error) {
+	b, err := d.store.Get(ctx, fmt.Sprintf(_manifestNameFormat, itemID))
+	if err == blobstore.ErrNotFound {
+		return manifest{}, err
+	}
+	if err != nil {
+		return manifest{}, errors.Wrapf(err, "failed to load icon manifest of item %v", itemID)
+	}
+
+	m := manifest{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return manifest{}, errors.Wrapf(err, "failed to decode icon manifest of item %v", itemID)
+	}
+	return m, nil
+}
+
+func (d *Downloader) download(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %v", u)
+	}
+
+	resp, err := d.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %v", u)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %v: unexpected status %v", u, resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %v", u)
+	}
+	return b, nil
+}
+
+func (d *Downloader) clearFailure(ctx context.Context, itemID int) error {
+	if err := d.loadFailures(ctx); err != nil {
+		return err
+	}
+	if _, ok := d.failures[itemID]; !ok {
+		return nil
+	}
+	delete(d.failures, itemID)
+	return d.saveFailures(ctx)
+}
+
+func (d *Downloader) loadFailures(ctx context.Context) error {
+	if d.failures != nil {
+		return nil
+	}
+
+	failures := make(map[int]time.Time)
+	b, err := d.store.Get(ctx, _failuresName)
+	if err != nil && err != blobstore.ErrNotFound {
+		return errors.Wrap(err, "failed to load icon failures")
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &failures); err != nil {
+			return errors.Wrap(err, "failed to decode icon failures")
+		}
+	}
+	d.failures = failures
+	return nil
+}
+
+func (d *Downloader) saveFailures(ctx context.Context) error {
+	b, err := json.Marshal(d.failures)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode icon failures")
+	}
+	if err := d.store.Put(ctx, _failuresName, b); err != nil {
+		return errors.Wrap(err, "failed to save icon failures")
+	}
+	return nil
+}
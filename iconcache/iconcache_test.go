@@ -0,0 +1,128 @@
+package iconcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+const _linenCloth = 2589
+
+// iconServer serves a fixed icon, failing while *fail is set, and counts the requests it gets.
+func iconServer(fail *bool, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if *fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("icon"))
+	}))
+}
+
+func iconMedia(s *httptest.Server) wowapiclient.ItemMedia {
+	return wowapiclient.ItemMedia{
+		ItemID: _linenCloth,
+		Assets: []wowapiclient.MediaAsset{{Key: "icon", URL: s.URL + "/inv_fabric_linen_01.jpg"}},
+	}
+}
+
+func TestFetch(t *testing.T) {
+	var (
+		fail     bool
+		requests int
+	)
+	s := iconServer(&fail, &requests)
+	defer s.Close()
+	ctx := context.Background()
+	store := blobstore.NewMemory()
+	d := NewDownloader(store, s.Client())
+
+	name, err := d.Fetch(ctx, iconMedia(s))
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	b, err := store.Get(ctx, name)
+	if err != nil || string(b) != "icon" {
+		t.Errorf("stored icon %v = %q, %v", name, b, err)
+	}
+	if got, err := d.IconName(ctx, _linenCloth); err != nil || got != name {
+		t.Errorf("IconName() = %q, %v, want %q", got, err, name)
+	}
+
+	// the manifest is a cache hit for the same URL
+	again, err := NewDownloader(store, s.Client()).Fetch(ctx, iconMedia(s))
+	if err != nil || again != name {
+		t.Errorf("second Fetch() = %q, %v, want %q", again, err, name)
+	}
+	if requests != 1 {
+		t.Errorf("downloaded the icon %v times, want 1", requests)
+	}
+}
+
+func TestFetchFailureRetry(t *testing.T) {
+	fail := true
+	var requests int
+	s := iconServer(&fail, &requests)
+	defer s.Close()
+	ctx := context.Background()
+	store := blobstore.NewMemory()
+
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	newDownloader := func() *Downloader {
+		d := NewDownloader(store, s.Client(), WithRetryInterval(time.Hour))
+		d.now = func() time.Time { return now }
+		return d
+	}
+
+	if _, err := newDownloader().Fetch(ctx, iconMedia(s)); err == nil {
+		t.Fatal("Fetch() of an unavailable icon error = nil")
+	}
+	if _, err := newDownloader().IconName(ctx, _linenCloth); err != blobstore.ErrNotFound {
+		t.Errorf("IconName() of a failed icon error = %v, want ErrNotFound", err)
+	}
+	if due, err := newDownloader().Due(ctx); err != nil || len(due) != 0 {
+		t.Errorf("Due() within the retry interval = %v, %v, want none", due, err)
+	}
+
+	now = now.Add(time.Hour)
+	due, err := newDownloader().Due(ctx)
+	if err != nil {
+		t.Fatalf("Due() error = %v", err)
+	}
+	if want := []int{_linenCloth}; !reflect.DeepEqual(due, want) {
+		t.Errorf("Due() after the retry interval = %v, want %v", due, want)
+	}
+
+	fail = false
+	d := newDownloader()
+	if _, err := d.Fetch(ctx, iconMedia(s)); err != nil {
+		t.Fatalf("retried Fetch() error = %v", err)
+	}
+	if due, err := newDownloader().Due(ctx); err != nil || len(due) != 0 {
+		t.Errorf("Due() after a successful retry = %v, %v, want none", due, err)
+	}
+	if requests != 2 {
+		t.Errorf("downloaded the icon %v times, want 2", requests)
+	}
+}
+
+func TestFailed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	d := NewDownloader(blobstore.NewMemory(), http.DefaultClient, WithRetryInterval(0))
+	d.now = func() time.Time { return now }
+
+	if err := d.Failed(ctx, _linenCloth); err != nil {
+		t.Fatalf("Failed() error = %v", err)
+	}
+	if due, err := d.Due(ctx); err != nil || !reflect.DeepEqual(due, []int{_linenCloth}) {
+		t.Errorf("Due() = %v, %v, want [%v]", due, err, _linenCloth)
+	}
+}
@@ -0,0 +1,177 @@
+// Package itemcache caches item metadata from the WOW API in a blobstore.Store so items only need to be
+// looked up once in a while.
+package itemcache
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_defaultTTL         = 7 * 24 * time.Hour
+	_defaultNegativeTTL = 24 * time.Hour
+)
+
+// Lookup fetches an item which is missing from the cache, usually WOWAPIClient.GetItem.
+type Lookup func(id int) (wowapiclient.Item, error)
+
+// Option configures a Cache.
+type Option func(*Cache)
+
+// WithTTL sets how long a found item is cached for.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithNegativeTTL sets how long an item which does not exist (for example because it was deleted from
+// the game) is cached for.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.negativeTTL = ttl
+	}
+}
+
+type entry struct {
+	Item      wowapiclient.Item `json:"item"`
+	Missing   bool              `json:"missing"`
+	FetchedAt time.Time         `json:"fetchedAt"`
+}
+
+// Cache is an item cache with expiry. It is not safe for concurrent use.
+type Cache struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	entries map[int]entry
+	dirty   bool
+}
+
+// New creates an empty Cache.
+func New(opts ...Option) *Cache {
+	c := &Cache{
+		ttl:         _defaultTTL,
+		negativeTTL: _defaultNegativeTTL,
+		now:         time.Now,
+		entries:     make(map[int]entry),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Load replaces the contents of the cache with the named blob, a missing blob leaves the cache empty.
+func (c *Cache) Load(ctx context.Context, store blobstore.Store, name string) error {
+	b, err := store.Get(ctx, name)
+	if err == blobstore.ErrNotFound {
+		c.entries = make(map[int]entry)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to load item cache")
+	}
+
+	entries := make(map[int]entry)
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return errors.Wrap(err, "failed to decode item cache")
+	}
+	c.entries = entries
+	c.dirty = false
+	return nil
+}
+
+// Save writes the cache to the named blob if anything changed since it was last loaded or saved.
+func (c *Cache) Save(ctx context.Context, store blobstore.Store, name string) error {
+	if !c.dirty {
+		return nil
+	}
+
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode item cache")
+	}
+	if err := store.Put(ctx, name, b); err != nil {
+		return errors.Wrap(err, "failed to save item cache")
+	}
+	c.dirty = false
+	return nil
+}
+
+// Get returns a cached item. The boolean is false if the item is not cached, or is cached as not
+// existing.
+func (c *Cache) Get(id int) (wowapiclient.Item, bool) {
+	e, ok := c.entries[id]
+	if !ok || e.Missing {
+		return wowapiclient.Item{}, false
+	}
+	return e.Item, true
+}
+
+// Stale returns the unique IDs from ids which are not cached or whose cache entry has expired, in the
+// order they first appear.
+func (c *Cache) Stale(ids []int) []int {
+	now := c.now()
+	seen := make(map[int]struct{}, len(ids))
+	var stale []int
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		e, ok := c.entries[id]
+		if !ok || now.Sub(e.FetchedAt) > c.expiry(e) {
+			stale = append(stale, id)
+		}
+	}
+	return stale
+}
+
+// Resolve looks up every stale item in ids. Items which the API reports as not found are cached as
+// missing.
+func (c *Cache) Resolve(ids []int, lookup Lookup) error {
+	for _, id := range c.Stale(ids) {
+		item, err := lookup(id)
+		if err != nil && !wowapiclient.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to resolve item %v", id)
+		}
+
+		c.entries[id] = entry{
+			Item:      item,
+			Missing:   err != nil,
+			FetchedAt: c.now(),
+		}
+		c.dirty = true
+	}
+	return nil
+}
+
+// Items returns every cached item which exists, ordered by ID. Expired items are included.
+func (c *Cache) Items() []wowapiclient.Item {
+	items := make([]wowapiclient.Item, 0, len(c.entries))
+	for _, e := range c.entries {
+		if !e.Missing {
+			items = append(items, e.Item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+func (c *Cache) expiry(e entry) time.Duration {
+	if e.Missing {
+		return c.negativeTTL
+	}
+	return c.ttl
+}
@@ -0,0 +1,136 @@
+package itemcache
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	_linenCloth = 2589
+	_peacebloom = 2447
+	_deleted    = 404404
+)
+
+func lookupFixtures(looked *[]int) Lookup {
+	items := map[int]wowapiclient.Item{
+		_linenCloth: {ID: _linenCloth, ItemClass: "Tradeskill"},
+		_peacebloom: {ID: _peacebloom, ItemClass: "Tradeskill"},
+	}
+	return func(id int) (wowapiclient.Item, error) {
+		*looked = append(*looked, id)
+		item, ok := items[id]
+		if !ok {
+			return wowapiclient.Item{}, &wowapiclient.StatusError{StatusCode: http.StatusNotFound}
+		}
+		return item, nil
+	}
+}
+
+func TestResolve(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	c := New(WithTTL(24*time.Hour), WithNegativeTTL(time.Hour))
+	c.now = func() time.Time { return now }
+
+	var looked []int
+	if err := c.Resolve([]int{_linenCloth, _deleted, _linenCloth}, lookupFixtures(&looked)); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := []int{_linenCloth, _deleted}; !reflect.DeepEqual(looked, want) {
+		t.Errorf("Resolve() looked up %v, want %v", looked, want)
+	}
+	if item, ok := c.Get(_linenCloth); !ok || item.ItemClass != "Tradeskill" {
+		t.Errorf("Get(%v) = %+v, %v", _linenCloth, item, ok)
+	}
+	if _, ok := c.Get(_deleted); ok {
+		t.Errorf("Get(%v) of a deleted item ok = true", _deleted)
+	}
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    []int
+	}{
+		{name: "nothing expired", elapsed: 30 * time.Minute, want: []int{_peacebloom}},
+		{name: "missing item expired", elapsed: 2 * time.Hour, want: []int{_deleted, _peacebloom}},
+		{name: "everything expired", elapsed: 25 * time.Hour, want: []int{_linenCloth, _deleted, _peacebloom}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.now = func() time.Time { return now.Add(tt.elapsed) }
+			if got := c.Stale([]int{_linenCloth, _deleted, _peacebloom}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveKeepsItemsResolvedBeforeAnError(t *testing.T) {
+	c := New()
+	failure := errors.New("connection reset")
+	err := c.Resolve([]int{_linenCloth, _peacebloom}, func(id int) (wowapiclient.Item, error) {
+		if id == _peacebloom {
+			return wowapiclient.Item{}, failure
+		}
+		return wowapiclient.Item{ID: id}, nil
+	})
+	if err == nil {
+		t.Fatal("Resolve() error = nil")
+	}
+	if _, ok := c.Get(_linenCloth); !ok {
+		t.Errorf("Get(%v) ok = false, want the item resolved before the error", _linenCloth)
+	}
+	if got := c.Stale([]int{_linenCloth, _peacebloom}); !reflect.DeepEqual(got, []int{_peacebloom}) {
+		t.Errorf("Stale() = %v, want %v", got, []int{_peacebloom})
+	}
+}
+
+// countingStore counts the blobs put into a Store.
+type countingStore struct {
+	blobstore.Store
+	puts int
+}
+
+func (s *countingStore) Put(ctx context.Context, name string, data []byte) error {
+	s.puts++
+	return s.Store.Put(ctx, name, data)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{Store: blobstore.NewMemory()}
+
+	c := New()
+	if err := c.Load(ctx, store, "items.json"); err != nil {
+		t.Fatalf("Load() of a missing cache error = %v", err)
+	}
+	var looked []int
+	if err := c.Resolve([]int{_linenCloth, _deleted}, lookupFixtures(&looked)); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.Save(ctx, store, "items.json"); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if store.puts != 1 {
+		t.Errorf("Save() of an unchanged cache wrote it %v times, want 1", store.puts)
+	}
+
+	loaded := New()
+	if err := loaded.Load(ctx, store, "items.json"); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Items(), c.Items()) || len(loaded.Items()) != 1 {
+		t.Errorf("Items() after Load() = %+v, want %+v", loaded.Items(), c.Items())
+	}
+	if got := loaded.Stale([]int{_linenCloth, _deleted}); len(got) != 0 {
+		t.Errorf("Stale() after Load() = %v, want nothing", got)
+	}
+}
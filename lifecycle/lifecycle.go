@@ -0,0 +1,223 @@
+// Package lifecycle tracks individual auctions across snapshots, from the first time they are seen until
+// they sell or expire.
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/analysis"
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/pkg/errors"
+)
+
+const _stateNameFormat = "lifecycle/%v.json"
+
+// Transition records the first snapshot in which an auction was seen with a TimeLeft.
+type Transition struct {
+	TimeLeft wowapiclient.TimeLeft
+	Time     time.Time
+}
+
+// Lifecycle is the history of a single auction.
+type Lifecycle struct {
+	RealmID   int
+	AuctionID int
+	ItemID    int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// EndedAt is the time of the first snapshot the auction was missing from, it is zero while the
+	// auction is still listed.
+	EndedAt time.Time
+	// SeenAtListing is false if the auction was already listed when tracking of the realm started, in
+	// which case FirstSeen is later than the real listing time.
+	SeenAtListing    bool
+	ListingUnitPrice int
+	ListingQuantity  int
+	SoldQuantity     int
+	Transitions      []Transition
+	// Disposition is either DispositionSold or DispositionExpired once the auction has ended.
+	Disposition analysis.Disposition
+}
+
+// ObservedAt returns when the auction was first seen with the given TimeLeft.
+func (l Lifecycle) ObservedAt(tl wowapiclient.TimeLeft) (time.Time, bool) {
+	for _, t := range l.Transitions {
+		if t.TimeLeft == tl {
+			return t.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Listed is the longest the auction could have been observed for, from FirstSeen until EndedAt.
+func (l Lifecycle) Listed() time.Duration {
+	if l.EndedAt.IsZero() {
+		return l.LastSeen.Sub(l.FirstSeen)
+	}
+	return l.EndedAt.Sub(l.FirstSeen)
+}
+
+type state struct {
+	LastSnapshot time.Time       `json:"lastSnapshot"`
+	Active       map[int]tracked `json:"active"`
+}
+
+type tracked struct {
+	Lifecycle Lifecycle            `json:"lifecycle"`
+	Last      wowapiclient.Auction `json:"last"`
+}
+
+// Tracker keeps the lifecycles of active auctions for every realm in a blobstore.Store between
+// snapshots.
+type Tracker struct {
+	store blobstore.Store
+}
+
+// NewTracker creates a Tracker which persists its state in store.
+func NewTracker(store blobstore.Store) *Tracker {
+	return &Tracker{store: store}
+}
+
+// Observation is a snapshot applied to the tracked state of its realm, which is only persisted by
+// Commit.
+type Observation struct {
+	// Completed are the lifecycles of every auction which ended since the previous snapshot, ordered by
+	// auction ID.
+	Completed []Lifecycle
+
+	realmID int
+	state   *state
+}
+
+// Observe applies a new snapshot to the tracked state of its realm. The new state is not saved until the
+// Observation is committed, so that the completed lifecycles can be stored first and are observed
+// again if storing them fails. Snapshots must be observed in order.
+func (t *Tracker) Observe(ctx context.Context, snap analysis.Snapshot) (*Observation, error) {
+	s, err := t.load(ctx, snap.RealmID)
+	if err != nil {
+		return nil, err
+	}
+
+	var completed []Lifecycle
+	if s.LastSnapshot.IsZero() {
+		for _, a := range snap.Auctions {
+			s.Active[a.ID] = start(snap, a, false)
+		}
+	} else {
+		completed, err = s.apply(snap)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply snapshot of realm %v", snap.RealmID)
+		}
+	}
+	s.LastSnapshot = snap.Time
+
+	return &Observation{Completed: completed, realmID: snap.RealmID, state: s}, nil
+}
+
+// Commit saves the state of o, after which its snapshot is the previous snapshot of the next Observe.
+func (t *Tracker) Commit(ctx context.Context, o *Observation) error {
+	return t.save(ctx, o.realmID, o.state)
+}
+
+func (s *state) apply(snap analysis.Snapshot) ([]Lifecycle, error) {
+	prev := analysis.Snapshot{
+		RealmID:  snap.RealmID,
+		Time:     s.LastSnapshot,
+		Auctions: make([]wowapiclient.Auction, 0, len(s.Active)),
+	}
+	for _, t := range s.Active {
+		prev.Auctions = append(prev.Auctions, t.Last)
+	}
+
+	diff, err := analysis.DiffSnapshots(prev, snap)
+	if err != nil {
+		return nil, err
+	}
+
+	var completed []Lifecycle
+	for _, c := range diff.Changes {
+		switch c.Disposition {
+		case analysis.DispositionNew:
+			s.Active[c.Auction.ID] = start(snap, c.Auction, true)
+		case analysis.DispositionListed, analysis.DispositionQuantityReduced:
+			t := s.Active[c.Auction.ID]
+			t.Lifecycle.LastSeen = snap.Time
+			t.Lifecycle.SoldQuantity += c.SoldQuantity
+			if t.Last.TimeLeft != c.Auction.TimeLeft {
+				t.Lifecycle.Transitions = append(t.Lifecycle.Transitions, Transition{
+					TimeLeft: c.Auction.TimeLeft,
+					Time:     snap.Time,
+				})
+			}
+			t.Last = c.Auction
+			s.Active[c.Auction.ID] = t
+		case analysis.DispositionSold, analysis.DispositionExpired:
+			t := s.Active[c.Auction.ID]
+			t.Lifecycle.EndedAt = snap.Time
+			t.Lifecycle.SoldQuantity += c.SoldQuantity
+			t.Lifecycle.Disposition = c.Disposition
+			completed = append(completed, t.Lifecycle)
+			delete(s.Active, c.Auction.ID)
+		default:
+			return nil, fmt.Errorf("unexpected disposition %q for auction %v", c.Disposition, c.Auction.ID)
+		}
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].AuctionID < completed[j].AuctionID
+	})
+	return completed, nil
+}
+
+func start(snap analysis.Snapshot, a wowapiclient.Auction, seenAtListing bool) tracked {
+	return tracked{
+		Lifecycle: Lifecycle{
+			RealmID:          snap.RealmID,
+			AuctionID:        a.ID,
+			ItemID:           a.ItemID,
+			FirstSeen:        snap.Time,
+			LastSeen:         snap.Time,
+			SeenAtListing:    seenAtListing,
+			ListingUnitPrice: a.EffectiveUnitPrice(),
+			ListingQuantity:  a.Quantity,
+			Transitions:      []Transition{{TimeLeft: a.TimeLeft, Time: snap.Time}},
+		},
+		Last: a,
+	}
+}
+
+func (t *Tracker) load(ctx context.Context, realmID int) (*state, error) {
+	s := &state{Active: make(map[int]tracked)}
+
+	b, err := t.store.Get(ctx, fmt.Sprintf(_stateNameFormat, realmID))
+	if err == blobstore.ErrNotFound {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load lifecycle state for realm %v", realmID)
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode lifecycle state for realm %v", realmID)
+	}
+	if s.Active == nil {
+		s.Active = make(map[int]tracked)
+	}
+	return s, nil
+}
+
+func (t *Tracker) save(ctx context.Context, realmID int, s *state) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode lifecycle state for realm %v", realmID)
+	}
+	if err := t.store.Put(ctx, fmt.Sprintf(_stateNameFormat, realmID), b); err != nil {
+		return errors.Wrapf(err, "failed to save lifecycle state for realm %v", realmID)
+	}
+	return nil
+}
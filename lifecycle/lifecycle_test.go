@@ -0,0 +1,202 @@
+package lifecycle
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/analysis"
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+func TestTrackerObserve(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time {
+		return start.Add(time.Duration(hours) * time.Hour)
+	}
+	sword := func(tl wowapiclient.TimeLeft) wowapiclient.Auction {
+		return wowapiclient.Auction{ID: 1, ItemID: 19019, Quantity: 1, Buyout: 5000, TimeLeft: tl}
+	}
+	cloth := func(quantity int) wowapiclient.Auction {
+		return wowapiclient.Auction{ID: 2, ItemID: 2589, Quantity: quantity, UnitPrice: 10, TimeLeft: wowapiclient.TimeLeftLong}
+	}
+
+	tests := []struct {
+		name      string
+		snapshots []analysis.Snapshot
+		want      []Lifecycle
+		wantErr   bool
+	}{
+		{
+			name: "first snapshot only starts tracking",
+			snapshots: []analysis.Snapshot{
+				{Time: at(0), Auctions: []wowapiclient.Auction{sword(wowapiclient.TimeLeftVeryLong)}},
+			},
+		},
+		{
+			name: "sold auction already listed when tracking started",
+			snapshots: []analysis.Snapshot{
+				{Time: at(0), Auctions: []wowapiclient.Auction{sword(wowapiclient.TimeLeftVeryLong)}},
+				{Time: at(1)},
+			},
+			want: []Lifecycle{{
+				AuctionID:        1,
+				ItemID:           19019,
+				FirstSeen:        at(0),
+				LastSeen:         at(0),
+				EndedAt:          at(1),
+				ListingUnitPrice: 5000,
+				ListingQuantity:  1,
+				SoldQuantity:     1,
+				Transitions:      []Transition{{TimeLeft: wowapiclient.TimeLeftVeryLong, Time: at(0)}},
+				Disposition:      analysis.DispositionSold,
+			}},
+		},
+		{
+			name: "expired auction seen at listing",
+			snapshots: []analysis.Snapshot{
+				{Time: at(0)},
+				{Time: at(1), Auctions: []wowapiclient.Auction{sword(wowapiclient.TimeLeftVeryLong)}},
+				{Time: at(30), Auctions: []wowapiclient.Auction{sword(wowapiclient.TimeLeftShort)}},
+				{Time: at(33)},
+			},
+			want: []Lifecycle{{
+				AuctionID:        1,
+				ItemID:           19019,
+				FirstSeen:        at(1),
+				LastSeen:         at(30),
+				EndedAt:          at(33),
+				SeenAtListing:    true,
+				ListingUnitPrice: 5000,
+				ListingQuantity:  1,
+				Transitions: []Transition{
+					{TimeLeft: wowapiclient.TimeLeftVeryLong, Time: at(1)},
+					{TimeLeft: wowapiclient.TimeLeftShort, Time: at(30)},
+				},
+				Disposition: analysis.DispositionExpired,
+			}},
+		},
+		{
+			name: "commodity sold in parts",
+			snapshots: []analysis.Snapshot{
+				{Time: at(0)},
+				{Time: at(1), Auctions: []wowapiclient.Auction{cloth(50)}},
+				{Time: at(2), Auctions: []wowapiclient.Auction{cloth(20)}},
+				{Time: at(3), Auctions: []wowapiclient.Auction{sword(wowapiclient.TimeLeftLong)}},
+			},
+			want: []Lifecycle{{
+				AuctionID:        2,
+				ItemID:           2589,
+				FirstSeen:        at(1),
+				LastSeen:         at(2),
+				EndedAt:          at(3),
+				SeenAtListing:    true,
+				ListingUnitPrice: 10,
+				ListingQuantity:  50,
+				SoldQuantity:     50,
+				Transitions:      []Transition{{TimeLeft: wowapiclient.TimeLeftLong, Time: at(1)}},
+				Disposition:      analysis.DispositionSold,
+			}},
+		},
+		{
+			name: "snapshots out of order",
+			snapshots: []analysis.Snapshot{
+				{Time: at(1)},
+				{Time: at(0)},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := blobstore.NewMemory()
+			var got []Lifecycle
+			var err error
+			for _, snap := range tt.snapshots {
+				// a new Tracker for every snapshot, as each cloud function invocation creates one
+				got, err = observe(NewTracker(store), snap)
+				if err != nil {
+					break
+				}
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Observe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Observe() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackerObserveRealmsApart(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	auctions := []wowapiclient.Auction{{ID: 1, ItemID: 19019, Quantity: 1, Buyout: 5000, TimeLeft: wowapiclient.TimeLeftVeryLong}}
+	tracker := NewTracker(blobstore.NewMemory())
+
+	if _, err := observe(tracker, analysis.Snapshot{RealmID: 61, Time: start, Auctions: auctions}); err != nil {
+		t.Fatalf("Observe(61) error = %v", err)
+	}
+	// the first snapshot of another realm only starts tracking it
+	got, err := observe(tracker, analysis.Snapshot{RealmID: 60, Time: start.Add(time.Hour)})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Observe(60) = %+v, %v, want nothing completed", got, err)
+	}
+	got, err = observe(tracker, analysis.Snapshot{RealmID: 61, Time: start.Add(time.Hour)})
+	if err != nil || len(got) != 1 || got[0].RealmID != 61 {
+		t.Errorf("Observe(61) = %+v, %v, want auction 1 of realm 61 completed", got, err)
+	}
+}
+
+func TestTrackerObserveUnknownTimeLeft(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	unknown := wowapiclient.Auction{ID: 1, ItemID: 19019, Quantity: 1, Buyout: 5000, TimeLeft: wowapiclient.TimeLeftUnknown}
+	tracker := NewTracker(blobstore.NewMemory())
+
+	// the state holding the unknown auction is read back by every later snapshot
+	for i, auctions := range [][]wowapiclient.Auction{{unknown}, {unknown}, nil} {
+		got, err := observe(tracker, analysis.Snapshot{RealmID: 61, Time: start.Add(time.Duration(i) * time.Hour), Auctions: auctions})
+		if err != nil {
+			t.Fatalf("Observe() of snapshot %v error = %v", i, err)
+		}
+		if i == 2 && (len(got) != 1 || got[0].AuctionID != 1) {
+			t.Errorf("Observe() of snapshot %v = %+v, want auction 1 completed", i, got)
+		}
+	}
+}
+
+func TestTrackerObserveWithoutCommit(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	auctions := []wowapiclient.Auction{{ID: 1, ItemID: 19019, Quantity: 1, Buyout: 5000, TimeLeft: wowapiclient.TimeLeftVeryLong}}
+	tracker := NewTracker(blobstore.NewMemory())
+	if _, err := observe(tracker, analysis.Snapshot{RealmID: 61, Time: start, Auctions: auctions}); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+
+	// storing the completed lifecycles failed, so the observation was not committed and is made again
+	for i := 0; i < 2; i++ {
+		o, err := tracker.Observe(context.Background(), analysis.Snapshot{RealmID: 61, Time: start.Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("Observe() error = %v", err)
+		}
+		if len(o.Completed) != 1 || o.Completed[0].AuctionID != 1 {
+			t.Errorf("Observe() attempt %v completed %+v, want auction 1", i, o.Completed)
+		}
+	}
+}
+
+// observe observes and commits snap.
+func observe(tracker *Tracker, snap analysis.Snapshot) ([]Lifecycle, error) {
+	o, err := tracker.Observe(context.Background(), snap)
+	if err != nil {
+		return nil, err
+	}
+	if err := tracker.Commit(context.Background(), o); err != nil {
+		return nil, err
+	}
+	return o.Completed, nil
+}
@@ -0,0 +1,134 @@
+// Package realmstatus records the status of connected realms over time and detects changes such as a
+// realm going down or a queue starting, which explain gaps in auction snapshots.
+package realmstatus
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+// Status is the status of a single connected realm.
+type Status struct {
+	ConnectedRealmID int
+	Status           wowapiclient.RealmStatus
+	HasQueue         bool
+	Population       wowapiclient.RealmPopulation
+}
+
+// Snapshot is the status of every connected realm at a point in time.
+type Snapshot struct {
+	Time   time.Time
+	Realms map[int]Status
+}
+
+// NewSnapshot takes a Snapshot of the given connected realms.
+func NewSnapshot(t time.Time, crs wowapiclient.ConnectedRealms) Snapshot {
+	s := Snapshot{
+		Time:   t,
+		Realms: make(map[int]Status, crs.Len()),
+	}
+	for _, cr := range crs.All() {
+		s.Realms[cr.ID] = Status{
+			ConnectedRealmID: cr.ID,
+			Status:           cr.Status,
+			HasQueue:         cr.HasQueue,
+			Population:       cr.Population,
+		}
+	}
+	return s
+}
+
+// Sorted returns the status of every connected realm ordered by connected realm ID.
+func (s Snapshot) Sorted() []Status {
+	statuses := make([]Status, 0, len(s.Realms))
+	for _, st := range s.Realms {
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ConnectedRealmID < statuses[j].ConnectedRealmID
+	})
+	return statuses
+}
+
+// EventType is a kind of change to a connected realm.
+type EventType string
+
+const (
+	// EventRealmDown means the realm went down.
+	EventRealmDown EventType = "REALM_DOWN"
+	// EventRealmUp means the realm came back up.
+	EventRealmUp EventType = "REALM_UP"
+	// EventQueueStarted means the realm started queueing logins.
+	EventQueueStarted EventType = "QUEUE_STARTED"
+	// EventQueueEnded means the realm stopped queueing logins.
+	EventQueueEnded EventType = "QUEUE_ENDED"
+	// EventPopulationChanged means the realm list population changed.
+	EventPopulationChanged EventType = "POPULATION_CHANGED"
+	// EventRealmAdded means the connected realm did not exist in the previous snapshot.
+	EventRealmAdded EventType = "REALM_ADDED"
+	// EventRealmRemoved means the connected realm no longer exists, usually because it was merged.
+	EventRealmRemoved EventType = "REALM_REMOVED"
+)
+
+// Event is a change to a connected realm between two snapshots. From and To describe the change, for
+// example the old and new population.
+type Event struct {
+	Time             time.Time
+	ConnectedRealmID int
+	Type             EventType
+	From             string
+	To               string
+}
+
+// Changes returns every change between two snapshots, ordered by connected realm ID. Events are
+// timestamped with the time of cur.
+func Changes(prev, cur Snapshot) []Event {
+	var events []Event
+	add := func(id int, t EventType, from, to string) {
+		events = append(events, Event{
+			Time:             cur.Time,
+			ConnectedRealmID: id,
+			Type:             t,
+			From:             from,
+			To:               to,
+		})
+	}
+
+	for _, c := range cur.Sorted() {
+		p, ok := prev.Realms[c.ConnectedRealmID]
+		if !ok {
+			add(c.ConnectedRealmID, EventRealmAdded, "", string(c.Status))
+			continue
+		}
+
+		if p.Status != c.Status {
+			t := EventRealmUp
+			if c.Status == wowapiclient.RealmStatusDown {
+				t = EventRealmDown
+			}
+			add(c.ConnectedRealmID, t, string(p.Status), string(c.Status))
+		}
+		if !p.HasQueue && c.HasQueue {
+			add(c.ConnectedRealmID, EventQueueStarted, "", "")
+		}
+		if p.HasQueue && !c.HasQueue {
+			add(c.ConnectedRealmID, EventQueueEnded, "", "")
+		}
+		if p.Population != c.Population {
+			add(c.ConnectedRealmID, EventPopulationChanged, string(p.Population), string(c.Population))
+		}
+	}
+
+	for _, p := range prev.Sorted() {
+		if _, ok := cur.Realms[p.ConnectedRealmID]; !ok {
+			add(p.ConnectedRealmID, EventRealmRemoved, string(p.Status), "")
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ConnectedRealmID < events[j].ConnectedRealmID
+	})
+	return events
+}
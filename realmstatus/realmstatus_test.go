@@ -0,0 +1,108 @@
+package realmstatus
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+func TestChanges(t *testing.T) {
+	prevTime := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	curTime := prevTime.Add(10 * time.Minute)
+	up := Status{ConnectedRealmID: 61, Status: wowapiclient.RealmStatusUp, Population: wowapiclient.RealmPopulationHigh}
+	snapshot := func(at time.Time, statuses ...Status) Snapshot {
+		s := Snapshot{Time: at, Realms: make(map[int]Status)}
+		for _, st := range statuses {
+			s.Realms[st.ConnectedRealmID] = st
+		}
+		return s
+	}
+	with := func(s Status, change func(*Status)) Status {
+		change(&s)
+		return s
+	}
+	event := func(id int, t EventType, from, to string) Event {
+		return Event{Time: curTime, ConnectedRealmID: id, Type: t, From: from, To: to}
+	}
+
+	tests := []struct {
+		name string
+		prev Snapshot
+		cur  Snapshot
+		want []Event
+	}{
+		{
+			name: "unchanged",
+			prev: snapshot(prevTime, up),
+			cur:  snapshot(curTime, up),
+		},
+		{
+			name: "realm down",
+			prev: snapshot(prevTime, up),
+			cur: snapshot(curTime, with(up, func(s *Status) {
+				s.Status = wowapiclient.RealmStatusDown
+			})),
+			want: []Event{event(61, EventRealmDown, "UP", "DOWN")},
+		},
+		{
+			name: "realm up",
+			prev: snapshot(prevTime, with(up, func(s *Status) {
+				s.Status = wowapiclient.RealmStatusDown
+			})),
+			cur:  snapshot(curTime, up),
+			want: []Event{event(61, EventRealmUp, "DOWN", "UP")},
+		},
+		{
+			name: "queue started with a population change",
+			prev: snapshot(prevTime, up),
+			cur: snapshot(curTime, with(up, func(s *Status) {
+				s.HasQueue = true
+				s.Population = wowapiclient.RealmPopulationFull
+			})),
+			want: []Event{
+				event(61, EventQueueStarted, "", ""),
+				event(61, EventPopulationChanged, "HIGH", "FULL"),
+			},
+		},
+		{
+			name: "queue ended",
+			prev: snapshot(prevTime, with(up, func(s *Status) {
+				s.HasQueue = true
+			})),
+			cur:  snapshot(curTime, up),
+			want: []Event{event(61, EventQueueEnded, "", "")},
+		},
+		{
+			name: "realms added and removed in connected realm order",
+			prev: snapshot(prevTime, up, Status{ConnectedRealmID: 3, Status: wowapiclient.RealmStatusUp}),
+			cur:  snapshot(curTime, up, Status{ConnectedRealmID: 70, Status: wowapiclient.RealmStatusUp}),
+			want: []Event{
+				event(3, EventRealmRemoved, "UP", ""),
+				event(70, EventRealmAdded, "", "UP"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Changes(tt.prev, tt.cur); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSnapshot(t *testing.T) {
+	crs := wowapiclient.NewConnectedRealms([]wowapiclient.ConnectedRealm{
+		{ID: 61, Status: wowapiclient.RealmStatusUp},
+		{ID: 60, Status: wowapiclient.RealmStatusDown, HasQueue: true},
+	})
+	s := NewSnapshot(time.Now(), crs)
+
+	sorted := s.Sorted()
+	if len(sorted) != 2 || sorted[0].ConnectedRealmID != 60 || !sorted[0].HasQueue || sorted[1].Status != wowapiclient.RealmStatusUp {
+		t.Errorf("Sorted() = %+v", sorted)
+	}
+}
@@ -0,0 +1,206 @@
+// Package cassette records HTTP responses from the Battle.net API to a file and replays them, so that
+// parsing failures seen in production can be reproduced deterministically. Credentials and oauth2
+// tokens are redacted before anything is written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const _redacted = "REDACTED"
+
+// _redactedHeaders are never written to a cassette.
+var _redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// _redactedParams are query parameters which are never written to a cassette.
+var _redactedParams = []string{"access_token", "client_id", "client_secret"}
+
+// _accessTokenPattern matches the token in an oauth2 token response.
+var _accessTokenPattern = regexp.MustCompile(`"access_token"\s*:\s*"[^"]*"`)
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Namespace is the Battlenet-Namespace header of the request.
+	Namespace  string      `json:"namespace,omitempty"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Cassette is every interaction recorded, in the order the requests were made.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads a cassette written by Recorder.Save.
+func Load(path string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cassette")
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode cassette %v", path)
+	}
+	return c, nil
+}
+
+// Recorder is an http.RoundTripper which records every response it gets from its base RoundTripper.
+type Recorder struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder records responses from base, http.DefaultTransport if it is nil.
+func NewRecorder(base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to record %v", req.URL)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del("Content-Length")
+	for _, h := range _redactedHeaders {
+		if header.Get(h) != "" {
+			header.Set(h, _redacted)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:     req.Method,
+		URL:        redactURL(req.URL),
+		Namespace:  req.Header.Get("Battlenet-Namespace"),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       _accessTokenPattern.ReplaceAllString(string(body), fmt.Sprintf(`"access_token":%q`, _redacted)),
+	})
+	return resp, nil
+}
+
+// Save writes everything recorded so far to path.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to encode cassette")
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return errors.Wrap(err, "failed to write cassette")
+	}
+	return nil
+}
+
+// Replayer is an http.RoundTripper which serves responses from a cassette instead of making requests.
+// Requests are matched by method, URL and namespace. A request made several times is served each
+// recorded response in turn, and fails once they are used up.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Interaction
+}
+
+// NewReplayer replays the interactions in c.
+func NewReplayer(c *Cassette) *Replayer {
+	r := &Replayer{responses: make(map[string][]Interaction)}
+	for _, i := range c.Interactions {
+		key := interactionKey(i.Method, i.URL, i.Namespace)
+		r.responses[key] = append(r.responses[key], i)
+	}
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := interactionKey(req.Method, redactURL(req.URL), req.Header.Get("Battlenet-Namespace"))
+
+	r.mu.Lock()
+	recorded := r.responses[key]
+	if len(recorded) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %v %v in namespace %q", req.Method, req.URL, req.Header.Get("Battlenet-Namespace"))
+	}
+	i := recorded[0]
+	r.responses[key] = recorded[1:]
+	r.mu.Unlock()
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+		StatusCode:    i.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(i.Body))),
+		ContentLength: int64(len(i.Body)),
+		Request:       req,
+	}, nil
+}
+
+// NewTransport returns a Recorder if record is set or a Replayer for the cassette at path otherwise, for
+// selecting a mode from command line flags. save writes the recording and does nothing when replaying.
+func NewTransport(path string, record bool) (rt http.RoundTripper, save func() error, err error) {
+	if record {
+		rec := NewRecorder(nil)
+		return rec, func() error { return rec.Save(path) }, nil
+	}
+
+	c, err := Load(path)
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, nil, errors.Wrapf(err, "no cassette at %v, record one first", path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewReplayer(c), func() error { return nil }, nil
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, p := range _redactedParams {
+		if query.Get(p) != "" {
+			query.Set(p, _redacted)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	redacted.User = nil
+	return redacted.String()
+}
+
+func interactionKey(method, u, namespace string) string {
+	return method + " " + u + " " + namespace
+}
@@ -0,0 +1,88 @@
+package cassette
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+)
+
+const _zuljinID = 61
+
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	s := wowapitest.NewServer(wowapitest.Fixtures{
+		ConnectedRealms: []wowapiclient.ConnectedRealm{{ID: _zuljinID}},
+		Auctions: map[int][]wowapiclient.Auction{
+			_zuljinID: {{ID: 1, ItemID: 2589, Quantity: 200, UnitPrice: 150, TimeLeft: wowapiclient.TimeLeftLong}},
+		},
+	})
+
+	rec := NewRecorder(s.Transport())
+	want := getAuctions(t, s, rec)
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	s.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "wowapitest-token") || strings.Contains(string(b), s.ClientSecret) {
+		t.Errorf("cassette contains credentials:\n%s", b)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	replayer := NewReplayer(c)
+	got := getAuctions(t, s, replayer)
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("replayed %+v, want %+v", got, want)
+	}
+
+	// every recorded response has been used
+	apiClient := newClient(t, s, NewReplayer(c))
+	if _, err := apiClient.GetAuctions(_zuljinID); err != nil {
+		t.Errorf("GetAuctions() error = %v", err)
+	}
+	if _, err := apiClient.GetAuctions(_zuljinID); err == nil {
+		t.Error("GetAuctions() beyond the recording error = nil")
+	}
+}
+
+func getAuctions(t *testing.T, s *wowapitest.Server, transport http.RoundTripper) []wowapiclient.Auction {
+	t.Helper()
+	auctions, err := newClient(t, s, transport).GetAuctions(_zuljinID)
+	if err != nil {
+		t.Fatalf("GetAuctions() error = %v", err)
+	}
+	return auctions
+}
+
+// newClient creates a client for the real API host whose requests go through transport.
+func newClient(t *testing.T, s *wowapitest.Server, transport http.RoundTripper) *wowapiclient.WOWAPIClient {
+	t.Helper()
+	httpClient, err := wowapiclient.GetHTTPClient(context.Background(), s.Secrets(), "us",
+		wowapiclient.WithTokenURL(s.TokenURL()),
+		wowapiclient.WithTransport(transport),
+	)
+	if err != nil {
+		t.Fatalf("failed to create http client: %v", err)
+	}
+	return wowapiclient.NewWOWAPIClient(httpClient, "us")
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
@@ -22,142 +21,177 @@ const (
 type WOWAPIClient struct {
 	httpClient *http.Client
 	region     string
-	apiHost    string
+	baseURL    *url.URL
+	userAgent  string
+	locale     Locale
+	flavor     GameFlavor
 }
 
 // NewWOWAPIClient creates a new WOWAPIClient
-func NewWOWAPIClient(client *http.Client, region string) *WOWAPIClient {
-	return &WOWAPIClient{
+func NewWOWAPIClient(client *http.Client, region string, opts ...ClientOption) *WOWAPIClient {
+	c := &WOWAPIClient{
 		httpClient: client,
 		region:     region,
-		apiHost:    fmt.Sprintf(_apiHostFormat, region),
+		baseURL:    &url.URL{Scheme: "https", Host: fmt.Sprintf(_apiHostFormat, region)},
+		locale:     LocaleEnUS,
+		flavor:     FlavorRetail,
 	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
 }
 
 // GetConnectedRealms gets all known connected realms in the clients region.
-func (c *WOWAPIClient) GetConnectedRealms() (ConnectedRealms, error) {
+func (c *WOWAPIClient) GetConnectedRealms(opts ...CallOption) (ConnectedRealms, error) {
 	// no args needed
-	apiArgs := url.Values{}
-	u := c.urlFromQueryAndPath("/data/wow/connected-realm/index", apiArgs)
-
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	addDynamicRegionNamespace(req, c.region)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	dc := json.NewDecoder(resp.Body)
 	parsedResponse := connectedRealmIndexResponse{}
-	err = dc.Decode(&parsedResponse)
-	if err != nil {
-		return nil, err
+	if err := c.callAPI("/data/wow/connected-realm/index", url.Values{}, &parsedResponse, c.newCallOptions(NamespaceDynamic, opts)); err != nil {
+		return ConnectedRealms{}, err
 	}
 
-	realms := make(ConnectedRealms, len(parsedResponse.ConnectedRealms))
+	realms := make([]ConnectedRealm, 0, len(parsedResponse.ConnectedRealms))
 	for _, realm := range parsedResponse.ConnectedRealms {
-		// drop the query string
-		url := strings.Split(realm.Href, "?")[0]
-		urlFrags := strings.Split(url, "/")
-		idStr := urlFrags[len(urlFrags)-1]
-		fmt.Printf("found id %v\n", idStr)
-		id, err := strconv.Atoi(idStr)
+		id, err := realm.ID()
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to convert ID string to int")
+			return ConnectedRealms{}, err
 		}
-		cr, err := c.getConnectedRealm(id)
+		cr, err := c.getConnectedRealm(id, opts)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to fetch connected realm %v", id)
-		}
-
-		for _, r := range cr.Realms {
-			realms[r] = cr
+			return ConnectedRealms{}, errors.Wrapf(err, "failed to fetch connected realm %v", id)
 		}
+		realms = append(realms, cr)
 	}
 
-	return realms, nil
+	return NewConnectedRealms(realms), nil
 }
 
-func (c *WOWAPIClient) getConnectedRealm(id int) (ConnectedRealm, error) {
+func (c *WOWAPIClient) getConnectedRealm(id int, opts []CallOption) (ConnectedRealm, error) {
 	// no args needed
-	apiArgs := url.Values{}
-	u := c.urlFromQueryAndPath(fmt.Sprintf("/data/wow/connected-realm/%v", id), apiArgs)
-
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return ConnectedRealm{}, err
-	}
-	addDynamicRegionNamespace(req, c.region)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return ConnectedRealm{}, err
-	}
-
-	dc := json.NewDecoder(resp.Body)
+	o := c.newCallOptions(NamespaceDynamic, opts)
 	parsedResponse := connectedRealmResponse{}
-	err = dc.Decode(&parsedResponse)
-	if err != nil {
+	if err := c.callAPI(fmt.Sprintf("/data/wow/connected-realm/%v", id), url.Values{}, &parsedResponse, o); err != nil {
 		return ConnectedRealm{}, err
 	}
 
 	cr := ConnectedRealm{
-		ID: id,
+		ID:         id,
+		HasQueue:   parsedResponse.HasQueue,
+		Status:     RealmStatus(parsedResponse.Status.Type),
+		Population: RealmPopulation(parsedResponse.Population.Type),
 	}
-	cr.Realms = make([]string, 0, len(parsedResponse.Realms))
+	cr.Realms = make([]Realm, 0, len(parsedResponse.Realms))
 	for _, r := range parsedResponse.Realms {
-		cr.Realms = append(cr.Realms, r.Name)
+		realm := r.toRealm(o.locale)
+		realm.ConnectedRealmID = id
+		cr.Realms = append(cr.Realms, realm)
 	}
 	return cr, nil
 }
 
 // GetItem gets an item from the wow API with the given ID.
-func (c *WOWAPIClient) GetItem(id int) (Item, error) {
-	// no url args needed
-	resp := itemResponse{}
-	if err := c.callAPI(fmt.Sprintf("/data/wow/item/%v", id), url.Values{}, &resp); err != nil {
+func (c *WOWAPIClient) GetItem(id int, opts ...CallOption) (Item, error) {
+	// the item endpoint always returns every detail, so share the call with GetItemDetail
+	detail, err := c.GetItemDetail(id, opts...)
+	if err != nil {
 		return Item{}, err
 	}
+	return detail.Item, nil
+}
 
-	return Item{
-		ID:             resp.ID,
-		Name:           resp.Name,
-		ItemClass:      resp.ItemClass.Name,
-		ItemClassID:    resp.ItemClass.ID,
-		ItemSubclass:   resp.ItemSubclass.Name,
-		ItemSubclassID: resp.ItemSubclass.ID,
-	}, nil
+// GetAuctions gets all auctions from the given connected realm ID. Classic connected realms have an
+// auction house per faction, use GetAuctionHouses and GetAuctionHouseAuctions for them instead.
+func (c *WOWAPIClient) GetAuctions(realmID int, opts ...CallOption) ([]Auction, error) {
+	return c.getAuctions(fmt.Sprintf("/data/wow/connected-realm/%v/auctions", realmID), realmID, AuctionHouse{}, opts)
 }
 
-// GetAuctions gets all auctions from the given connected realm ID
-func (c *WOWAPIClient) GetAuctions(realmID int) ([]Auction, error) {
+// GetAuctionHouses gets the auction houses of a classic connected realm.
+func (c *WOWAPIClient) GetAuctionHouses(realmID int, opts ...CallOption) ([]AuctionHouse, error) {
+	o := c.newCallOptions(NamespaceDynamic, opts)
+	resp := auctionHouseIndexResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/connected-realm/%v/auctions/index", realmID), url.Values{}, &resp, o); err != nil {
+		return nil, err
+	}
+
+	houses := make([]AuctionHouse, 0, len(resp.Auctions))
+	for _, ah := range resp.Auctions {
+		houses = append(houses, AuctionHouse{
+			ID:      ah.ID,
+			Name:    ah.Name.inLocale(o.locale).String(),
+			Faction: _auctionHouseFactions[ah.ID],
+		})
+	}
+	return houses, nil
+}
+
+// GetAuctionHouseAuctions gets all auctions from a single auction house of a classic connected realm.
+func (c *WOWAPIClient) GetAuctionHouseAuctions(realmID int, ah AuctionHouse, opts ...CallOption) ([]Auction, error) {
+	return c.getAuctions(fmt.Sprintf("/data/wow/connected-realm/%v/auctions/%v", realmID, ah.ID), realmID, ah, opts)
+}
+
+// GetAllAuctions gets every auction on a connected realm in the client's flavor, with GetAuctions for
+// retail and from each of its auction houses for classic flavors.
+func (c *WOWAPIClient) GetAllAuctions(realmID int, opts ...CallOption) ([]Auction, error) {
+	if c.flavor == FlavorRetail {
+		return c.GetAuctions(realmID, opts...)
+	}
+
+	houses, err := c.GetAuctionHouses(realmID, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get auction houses of %v", realmID)
+	}
+
+	var auctions []Auction
+	for _, ah := range houses {
+		a, err := c.GetAuctionHouseAuctions(realmID, ah, opts...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get auctions of %v auction house %v", realmID, ah.ID)
+		}
+		auctions = append(auctions, a...)
+	}
+	return auctions, nil
+}
+
+func (c *WOWAPIClient) getAuctions(path string, realmID int, ah AuctionHouse, opts []CallOption) ([]Auction, error) {
 	// no url args needed
 	resp := auctionsResponse{}
-	if err := c.callAPI(fmt.Sprintf("/data/wow/connected-realm/%v/auctions", realmID), url.Values{}, &resp); err != nil {
+	o := c.newCallOptions(NamespaceDynamic, opts)
+	if err := c.callAPI(path, url.Values{}, &resp, o); err != nil {
 		return nil, err
 	}
 
 	auctions := make([]Auction, 0, len(resp.Auctions))
 	for _, a := range resp.Auctions {
+		timeLeft, timeLeftErr := parseTimeLeft(a.TimeLeft)
+		if timeLeftErr != nil {
+			if o.quarantine == nil {
+				return nil, timeLeftErr
+			}
+			timeLeft = TimeLeftUnknown
+		}
+
 		auction := Auction{
-			RealmID:   realmID,
-			ID:        a.ID,
-			ItemID:    a.Item.ID,
-			Quantity:  a.Quantity,
-			UnitPrice: a.UnitPrice,
-			Buyout:    a.Buyout,
-			Bid:       a.Bid,
-			TimeLeft:  a.TimeLeft,
+			Flavor:         c.flavor,
+			RealmID:        realmID,
+			AuctionHouseID: ah.ID,
+			Faction:        ah.Faction,
+			ID:             a.ID,
+			ItemID:         a.Item.ID,
+			Quantity:       a.Quantity,
+			UnitPrice:      a.UnitPrice,
+			Buyout:         a.Buyout,
+			Bid:            a.Bid,
+			TimeLeft:       timeLeft,
 		}
-		if auction.Quantity == 0 {
-			return nil, fmt.Errorf("auction id %v of %v has a quantity of 0", auction.ID, auction.ItemID)
+		if err := auction.validate(); err != nil {
+			if o.quarantine == nil {
+				return nil, err
+			}
+			o.quarantine.add(auction, err, false)
+			continue
 		}
-		if auction.Buyout == 0 && auction.UnitPrice == 0 && auction.Bid == 0 {
-			return nil, fmt.Errorf("auction id %v of %v has a buyout of 0 and a unitprice of 0 and a bid of 0", auction.ID, auction.ItemID)
+		if timeLeftErr != nil {
+			o.quarantine.add(auction, timeLeftErr, true)
 		}
 
 		auctions = append(auctions, auction)
@@ -166,19 +200,29 @@ func (c *WOWAPIClient) GetAuctions(realmID int) ([]Auction, error) {
 	return auctions, nil
 }
 
-func (c *WOWAPIClient) callAPI(path string, queryArgs url.Values, responseTarget interface{}) error {
-	u := c.urlFromQueryAndPath(path, queryArgs)
+// callAPI calls the API at path with the namespace and locale from o and decodes the response into
+// responseTarget.
+func (c *WOWAPIClient) callAPI(path string, queryArgs url.Values, responseTarget interface{}, o callOptions) error {
+	u := c.urlFromQueryAndPath(path, queryArgs, o.locale)
 
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
 		return errors.Wrapf(err, "failed to call %v", u.String())
 	}
-	addDynamicRegionNamespace(req, c.region)
+	addNamespace(req, o.fullNamespace(c.region))
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return errors.Wrapf(err, "failed to call %v", u.String())
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrapf(&StatusError{StatusCode: resp.StatusCode}, "failed to call %v", u.String())
+	}
 
 	dc := json.NewDecoder(resp.Body)
 	err = dc.Decode(responseTarget)
@@ -189,79 +233,189 @@ func (c *WOWAPIClient) callAPI(path string, queryArgs url.Values, responseTarget
 	return nil
 }
 
-func (c *WOWAPIClient) urlFromQueryAndPath(path string, values url.Values) *url.URL {
-	// Force all output to en_US for now
-	values.Add("locale", "en_US")
+// StatusError is returned when the API responds with anything other than 200 OK.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %v %v", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// IsNotFound returns true if err was caused by the API responding 404 Not Found, for example when
+// fetching an item which has been removed from the game.
+func IsNotFound(err error) bool {
+	se, ok := errors.Cause(err).(*StatusError)
+	return ok && se.StatusCode == http.StatusNotFound
+}
+
+func (c *WOWAPIClient) urlFromQueryAndPath(path string, values url.Values, locale Locale) *url.URL {
+	// the API returns every locale when none is given
+	if locale != LocaleAll {
+		values.Set("locale", string(locale))
+	}
 
 	return &url.URL{
-		Scheme:   "https",
-		Host:     c.apiHost,
-		Path:     path,
+		Scheme:   c.baseURL.Scheme,
+		Host:     c.baseURL.Host,
+		Path:     strings.TrimSuffix(c.baseURL.Path, "/") + path,
 		RawQuery: values.Encode(),
 	}
 }
 
-// ConnectedRealm is the smallest set of information about a connected realm that is needed
+// ConnectedRealm is a group of realms which share an auction house, along with its current status.
 type ConnectedRealm struct {
-	Realms []string
-	ID     int
+	Realms     []Realm
+	ID         int
+	HasQueue   bool
+	Status     RealmStatus
+	Population RealmPopulation
 }
 
-func addDynamicRegionNamespace(req *http.Request, region string) {
-	req.Header.Add("Battlenet-Namespace", fmt.Sprintf("dynamic-%s", region))
+// Realm is a single realm within a ConnectedRealm.
+type Realm struct {
+	ID               int
+	ConnectedRealmID int
+	Name             LocalizedString
+	Slug             string
+	// Category is the realm list category, such as "United States" or "Oceanic".
+	Category LocalizedString
+	// Locale is the language of the realm, such as "enUS".
+	Locale       string
+	Timezone     string
+	Type         RealmType
+	IsTournament bool
 }
 
+// RealmStatus is whether a connected realm is up.
+type RealmStatus string
+
+const (
+	// RealmStatusUp means the realm is up.
+	RealmStatusUp RealmStatus = "UP"
+	// RealmStatusDown means the realm is down.
+	RealmStatusDown RealmStatus = "DOWN"
+)
+
+// RealmPopulation is the population of a connected realm as shown on the realm list.
+type RealmPopulation string
+
+const (
+	// RealmPopulationLow is a low population realm.
+	RealmPopulationLow RealmPopulation = "LOW"
+	// RealmPopulationMedium is a medium population realm.
+	RealmPopulationMedium RealmPopulation = "MEDIUM"
+	// RealmPopulationHigh is a high population realm.
+	RealmPopulationHigh RealmPopulation = "HIGH"
+	// RealmPopulationFull is a full realm, it may have a queue.
+	RealmPopulationFull RealmPopulation = "FULL"
+	// RealmPopulationLocked is a realm which does not allow new characters.
+	RealmPopulationLocked RealmPopulation = "LOCKED"
+)
+
+// RealmType is the ruleset of a realm.
+type RealmType string
+
+const (
+	// RealmTypeNormal is a normal realm.
+	RealmTypeNormal RealmType = "NORMAL"
+	// RealmTypeRP is a roleplaying realm.
+	RealmTypeRP RealmType = "RP"
+	// RealmTypePvP is a player versus player realm.
+	RealmTypePvP RealmType = "PVP"
+	// RealmTypeRPPvP is a roleplaying player versus player realm.
+	RealmTypeRPPvP RealmType = "RPPVP"
+)
+
 type connectedRealmIndexResponse struct {
-	Links           map[string]link `json:"_links"`
-	ConnectedRealms []link          `json:"connected_realms"`
+	responseLinks
+	ConnectedRealms []Link `json:"connected_realms"`
 }
 
 type connectedRealmResponse struct {
-	Links    map[string]link `json:"_links"`
-	ID       int             `json:"id"`
-	HasQueue bool            `json:"has_queue"`
-	Realms   []realmResponse `json:"realms"`
+	responseLinks
+	ID         int             `json:"id"`
+	HasQueue   bool            `json:"has_queue"`
+	Status     typedName       `json:"status"`
+	Population typedName       `json:"population"`
+	Realms     []realmResponse `json:"realms"`
 }
 
 type realmResponse struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	responseLinks
+	ConnectedRealm Link            `json:"connected_realm"`
+	ID             int             `json:"id"`
+	Name           LocalizedString `json:"name"`
+	Slug           string          `json:"slug"`
+	Category       LocalizedString `json:"category"`
+	Locale         string          `json:"locale"`
+	Timezone       string          `json:"timezone"`
+	Type           typedName       `json:"type"`
+	IsTournament   bool            `json:"is_tournament"`
 }
 
-type link struct {
-	Href string
+func (r realmResponse) toRealm(l Locale) Realm {
+	return Realm{
+		ID:           r.ID,
+		Name:         r.Name.inLocale(l),
+		Slug:         r.Slug,
+		Category:     r.Category.inLocale(l),
+		Locale:       r.Locale,
+		Timezone:     r.Timezone,
+		Type:         RealmType(r.Type.Type),
+		IsTournament: r.IsTournament,
+	}
 }
 
-// ConnectedRealms is a collection of all ConnectedRealm's which can be fetched by friendly name
-// rather than ID.
-type ConnectedRealms map[string]ConnectedRealm
-
-// Get a realm from ConnectedRealms, returns an error if that friendly name doesn't exist
-func (cr ConnectedRealms) Get(n string) (ConnectedRealm, error) {
-	if r, ok := cr[strings.ToLower(n)]; ok {
-		return r, nil
-	}
-	return ConnectedRealm{}, fmt.Errorf("unknown connected realm %v", n)
+// typedName is an enum from the API with its localized name.
+type typedName struct {
+	Type string          `json:"type"`
+	Name LocalizedString `json:"name"`
 }
 
 type itemResponse struct {
-	Links        map[string]link `json:"_links"`
+	responseLinks
 	ID           int             `json:"id"`
-	Name         string          `json:"name"`
-	ItemClass    itemClass
-	ItemSubclass itemClass
+	Name         LocalizedString `json:"name"`
+	ItemClass    itemClass       `json:"item_class"`
+	ItemSubclass itemClass       `json:"item_subclass"`
+
+	Quality          typedName `json:"quality"`
+	Level            int       `json:"level"`
+	RequiredLevel    int       `json:"required_level"`
+	InventoryType    typedName `json:"inventory_type"`
+	PurchasePrice    int       `json:"purchase_price"`
+	PurchaseQuantity int       `json:"purchase_quantity"`
+	SellPrice        int       `json:"sell_price"`
+	MaxCount         int       `json:"max_count"`
+	IsEquippable     bool      `json:"is_equippable"`
+	IsStackable      bool      `json:"is_stackable"`
+	PreviewItem      struct {
+		Binding typedName `json:"binding"`
+	} `json:"preview_item"`
+}
+
+func (r itemResponse) toItem(l Locale) Item {
+	return Item{
+		ID:             r.ID,
+		Name:           r.Name.inLocale(l),
+		ItemClass:      r.ItemClass.Name.String(),
+		ItemClassID:    r.ItemClass.ID,
+		ItemSubclass:   r.ItemSubclass.Name.String(),
+		ItemSubclassID: r.ItemSubclass.ID,
+	}
 }
 
 type itemClass struct {
-	Key  map[string]link `json:"key"`
-	Name string          `json:"name"`
+	Key  Link            `json:"key"`
+	Name LocalizedString `json:"name"`
 	ID   int             `json:"id"`
 }
 
 // Item is a minimal representation of an ingame item.
 type Item struct {
 	ID             int
-	Name           string
+	Name           LocalizedString
 	ItemClass      string
 	ItemClassID    int
 	ItemSubclass   string
@@ -269,8 +423,8 @@ type Item struct {
 }
 
 type auctionsResponse struct {
-	Links          map[string]link   `json:"_links"`
-	ConnectedRealm link              `json:"connected_realm"`
+	responseLinks
+	ConnectedRealm Link              `json:"connected_realm"`
 	Auctions       []auctionResponse `json:"auctions"`
 }
 
@@ -279,48 +433,125 @@ type auctionResponse struct {
 	Item struct {
 		ID int `json:"id"`
 	} `json:"item"`
-	Quantity  int      `json:"quantity"`
-	UnitPrice int      `json:"unit_price,omitempty"`
-	Buyout    int      `json:"buyout,omitempty"`
-	Bid       int      `json:"bid"`
-	TimeLeft  TimeLeft `json:"time_left"`
+	Quantity  int `json:"quantity"`
+	UnitPrice int `json:"unit_price,omitempty"`
+	Buyout    int `json:"buyout,omitempty"`
+	Bid       int `json:"bid"`
+	// TimeLeft is parsed after decoding so that unknown values can be tolerated.
+	TimeLeft string `json:"time_left"`
 }
 
 // Auction represents a single auction within a single region. Currently this does not support
 // Items with "bonuses" or "modifiers" such as sockets, ilvl upgrades (warforge), or extra secondaries
-// such as Indestructible or Speed. An Auction will either have a Buyout price or a Bid price, or a UnitPrice. If Buyout
-// or Bid >0 it should be used.
+// such as Indestructible or Speed. An Auction will either have a Buyout price or a Bid price, or a UnitPrice.
+// Buyout and Bid are prices for the whole stack while UnitPrice (used for commodities) is per unit, use
+// EffectiveUnitPrice and EffectiveTotal rather than reading the prices directly.
 type Auction struct {
-	RealmID   int
-	ID        int
-	ItemID    int
-	Quantity  int
-	UnitPrice int
-	Buyout    int
-	Bid       int
-	TimeLeft  TimeLeft
+	// Flavor is the GameFlavor of the client which fetched the auction.
+	Flavor  GameFlavor
+	RealmID int
+	// AuctionHouseID and Faction are only set for classic auctions.
+	AuctionHouseID int
+	Faction        Faction
+	ID             int
+	ItemID         int
+	Quantity       int
+	UnitPrice      int
+	Buyout         int
+	Bid            int
+	TimeLeft       TimeLeft
+}
+
+// HasBuyout returns true if the auction can be bought outright, either as a whole stack or per unit.
+func (a Auction) HasBuyout() bool {
+	return a.UnitPrice > 0 || a.Buyout > 0
+}
+
+// IsBidOnly returns true if the auction can only be bid on.
+func (a Auction) IsBidOnly() bool {
+	return !a.HasBuyout() && a.Bid > 0
+}
+
+// EffectiveTotal is the price of the whole auction. UnitPrice is preferred, then Buyout, then Bid.
+func (a Auction) EffectiveTotal() int {
+	switch {
+	case a.UnitPrice > 0:
+		return a.UnitPrice * a.Quantity
+	case a.Buyout > 0:
+		return a.Buyout
+	default:
+		return a.Bid
+	}
+}
+
+// EffectiveUnitPrice is the price of a single unit in the auction. Stack prices are divided by Quantity
+// and truncated to the nearest copper. An auction with no Quantity has an EffectiveUnitPrice of 0.
+func (a Auction) EffectiveUnitPrice() int {
+	if a.UnitPrice > 0 {
+		return a.UnitPrice
+	}
+	if a.Quantity == 0 {
+		return 0
+	}
+	return a.EffectiveTotal() / a.Quantity
+}
+
+func (a Auction) validate() error {
+	if a.Quantity <= 0 {
+		return fmt.Errorf("auction id %v of %v has a quantity of %v", a.ID, a.ItemID, a.Quantity)
+	}
+	if a.EffectiveTotal() <= 0 {
+		return fmt.Errorf("auction id %v of %v has a buyout of 0 and a unitprice of 0 and a bid of 0", a.ID, a.ItemID)
+	}
+	return nil
 }
 
 // TimeLeft represents how much time is left in an auction. The API makes this deliberately imprecise.
 type TimeLeft string
 
-// UnmarshalJSON unmarshals a TimeLeft from a json field.
+// UnmarshalJSON unmarshals a TimeLeft from a json field. TimeLeftUnknown is accepted so that auctions
+// kept WithQuarantine can be stored and read back.
 func (tl *TimeLeft) UnmarshalJSON(b []byte) error {
-	toMatch := strings.Trim(string(b), `"`)
-	switch TimeLeft(toMatch) {
+	s := strings.Trim(string(b), `"`)
+	if TimeLeft(s) == TimeLeftUnknown {
+		*tl = TimeLeftUnknown
+		return nil
+	}
+	parsed, err := parseTimeLeft(s)
+	if err != nil {
+		return err
+	}
+	*tl = parsed
+	return nil
+}
+
+func parseTimeLeft(s string) (TimeLeft, error) {
+	switch TimeLeft(s) {
 	case TimeLeftVeryLong:
-		*tl = TimeLeftVeryLong
+		return TimeLeftVeryLong, nil
 	case TimeLeftLong:
-		*tl = TimeLeftLong
+		return TimeLeftLong, nil
 	case TimeLeftMedium:
-		*tl = TimeLeftMedium
+		return TimeLeftMedium, nil
 	case TimeLeftShort:
-		*tl = TimeLeftShort
+		return TimeLeftShort, nil
 	default:
-		return fmt.Errorf("cannot unmarshal %q as TimeLeft", toMatch)
+		return "", fmt.Errorf("cannot unmarshal %q as TimeLeft", s)
 	}
+}
 
-	return nil
+// MinRemaining is the least amount of time that may be left on an auction with this TimeLeft.
+func (tl TimeLeft) MinRemaining() time.Duration {
+	switch tl {
+	case TimeLeftMedium:
+		return 2 * time.Hour
+	case TimeLeftLong:
+		return 12 * time.Hour
+	case TimeLeftVeryLong:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
 }
 
 const (
@@ -332,4 +563,7 @@ const (
 	TimeLeftLong = "LONG"
 	// TimeLeftVeryLong means there is more than 24 hours left on the auction.
 	TimeLeftVeryLong = "VERY_LONG"
+	// TimeLeftUnknown is given to auctions with a time left the client does not recognise when
+	// fetched WithQuarantine. Nothing is known about how much time is left on them.
+	TimeLeftUnknown = "UNKNOWN"
 )
@@ -0,0 +1,423 @@
+package wowapiclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+	"github.com/pkg/errors"
+)
+
+const (
+	_zuljinID   = 61
+	_stormrage  = 60
+	_linenCloth = 2589
+	_peacebloom = 2447
+	_missing    = 404404
+)
+
+func testFixtures() wowapitest.Fixtures {
+	return wowapitest.Fixtures{
+		ConnectedRealms: []wowapiclient.ConnectedRealm{
+			{
+				ID:         _zuljinID,
+				Status:     wowapiclient.RealmStatusUp,
+				Population: wowapiclient.RealmPopulationHigh,
+				Realms: []wowapiclient.Realm{{
+					ID:       61,
+					Name:     wowapiclient.LocalizedString{wowapiclient.LocaleEnUS: "Zul'jin", wowapiclient.LocaleEsMX: "Zul'jin"},
+					Slug:     "zuljin",
+					Category: wowapiclient.LocalizedString{wowapiclient.LocaleEnUS: "United States"},
+					Locale:   "enUS",
+					Timezone: "America/New_York",
+					Type:     wowapiclient.RealmTypeNormal,
+				}},
+			},
+			{
+				ID:         _stormrage,
+				HasQueue:   true,
+				Status:     wowapiclient.RealmStatusDown,
+				Population: wowapiclient.RealmPopulationFull,
+				Realms: []wowapiclient.Realm{{
+					ID:       60,
+					Name:     wowapiclient.LocalizedString{wowapiclient.LocaleEnUS: "Stormrage"},
+					Slug:     "stormrage",
+					Locale:   "enUS",
+					Timezone: "America/New_York",
+					Type:     wowapiclient.RealmTypeNormal,
+				}},
+			},
+		},
+		Auctions: map[int][]wowapiclient.Auction{
+			_zuljinID: {
+				{ID: 1, ItemID: 19019, Quantity: 1, Buyout: 5000000, TimeLeft: wowapiclient.TimeLeftVeryLong},
+				{ID: 2, ItemID: 19019, Quantity: 1, Bid: 4000000, TimeLeft: wowapiclient.TimeLeftShort},
+			},
+		},
+		Commodities: []wowapiclient.Auction{
+			{ID: 3, ItemID: _linenCloth, Quantity: 200, UnitPrice: 150, TimeLeft: wowapiclient.TimeLeftLong},
+			{ID: 4, ItemID: _peacebloom, Quantity: 20, UnitPrice: 75, TimeLeft: wowapiclient.TimeLeftMedium},
+		},
+		Items: []wowapiclient.ItemDetail{{
+			Item: wowapiclient.Item{
+				ID:             _linenCloth,
+				Name:           wowapiclient.LocalizedString{wowapiclient.LocaleEnUS: "Linen Cloth", wowapiclient.LocaleEsMX: "Paño de lino"},
+				ItemClass:      "Tradeskill",
+				ItemClassID:    7,
+				ItemSubclass:   "Cloth",
+				ItemSubclassID: 5,
+			},
+			Quality:       wowapiclient.QualityCommon,
+			InventoryType: "NON_EQUIP",
+			SellPrice:     13,
+			MaxCount:      0,
+			IsStackable:   true,
+		}},
+	}
+}
+
+func newTestClient(t *testing.T, s *wowapitest.Server, opts ...wowapiclient.ClientOption) *wowapiclient.WOWAPIClient {
+	t.Helper()
+	c, err := s.NewClient(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return c
+}
+
+func TestGetConnectedRealms(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	crs, err := newTestClient(t, s).GetConnectedRealms()
+	if err != nil {
+		t.Fatalf("GetConnectedRealms() error = %v", err)
+	}
+	if crs.Len() != 2 {
+		t.Fatalf("GetConnectedRealms() got %v connected realms, want 2", crs.Len())
+	}
+
+	cr, err := crs.Get("Zul'jin")
+	if err != nil {
+		t.Fatalf("Get(Zul'jin) error = %v", err)
+	}
+	if cr.ID != _zuljinID || cr.Status != wowapiclient.RealmStatusUp || cr.Population != wowapiclient.RealmPopulationHigh {
+		t.Errorf("Get(Zul'jin) = %+v", cr)
+	}
+	r := cr.Realms[0]
+	if r.ConnectedRealmID != _zuljinID || r.Slug != "zuljin" || r.Timezone != "America/New_York" || r.Type != wowapiclient.RealmTypeNormal {
+		t.Errorf("Get(Zul'jin) realm = %+v", r)
+	}
+	if got := r.Name.String(); got != "Zul'jin" {
+		t.Errorf("realm name = %q, want Zul'jin", got)
+	}
+
+	cr, err = crs.GetByID(_stormrage)
+	if err != nil {
+		t.Fatalf("GetByID(%v) error = %v", _stormrage, err)
+	}
+	if !cr.HasQueue || cr.Status != wowapiclient.RealmStatusDown {
+		t.Errorf("GetByID(%v) = %+v, want a queue and down", _stormrage, cr)
+	}
+}
+
+func TestGetConnectedRealmsAllLocales(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	crs, err := newTestClient(t, s).GetConnectedRealms(wowapiclient.WithLocale(wowapiclient.LocaleAll))
+	if err != nil {
+		t.Fatalf("GetConnectedRealms() error = %v", err)
+	}
+	cr, err := crs.GetBySlug("zuljin")
+	if err != nil {
+		t.Fatalf("GetBySlug(zuljin) error = %v", err)
+	}
+	if got := len(cr.Realms[0].Name); got != 2 {
+		t.Errorf("realm name has %v locales, want 2", got)
+	}
+}
+
+func TestGetAuctions(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	auctions, err := newTestClient(t, s).GetAuctions(_zuljinID)
+	if err != nil {
+		t.Fatalf("GetAuctions() error = %v", err)
+	}
+	if len(auctions) != 2 {
+		t.Fatalf("GetAuctions() got %v auctions, want 2", len(auctions))
+	}
+	for _, a := range auctions {
+		if a.RealmID != _zuljinID || a.Flavor != wowapiclient.FlavorRetail {
+			t.Errorf("auction %v has realm %v and flavor %v", a.ID, a.RealmID, a.Flavor)
+		}
+	}
+	if !auctions[1].IsBidOnly() || auctions[1].TimeLeft != wowapiclient.TimeLeftShort {
+		t.Errorf("auction 2 = %+v, want a bid only short auction", auctions[1])
+	}
+}
+
+func TestGetAuctionsWithQuarantine(t *testing.T) {
+	fixtures := testFixtures()
+	fixtures.Auctions[_zuljinID] = append(fixtures.Auctions[_zuljinID],
+		wowapiclient.Auction{ID: 5, ItemID: 19019, Quantity: 0, Buyout: 5000000, TimeLeft: wowapiclient.TimeLeftLong},
+		wowapiclient.Auction{ID: 6, ItemID: 19019, Quantity: 1, TimeLeft: wowapiclient.TimeLeftLong},
+		wowapiclient.Auction{ID: 7, ItemID: 19019, Quantity: 1, Buyout: 5000000, TimeLeft: "FOREVER"},
+	)
+	s := wowapitest.NewServer(fixtures)
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	if _, err := c.GetAuctions(_zuljinID); err == nil {
+		t.Error("GetAuctions() of invalid auctions error = nil")
+	}
+
+	var q wowapiclient.Quarantine
+	auctions, err := c.GetAuctions(_zuljinID, wowapiclient.WithQuarantine(&q))
+	if err != nil {
+		t.Fatalf("GetAuctions() error = %v", err)
+	}
+	if len(auctions) != 3 {
+		t.Fatalf("GetAuctions() got %v auctions, want 3", len(auctions))
+	}
+	if got := auctions[2]; got.ID != 7 || got.TimeLeft != wowapiclient.TimeLeftUnknown {
+		t.Errorf("auction 7 = %+v, want an unknown time left", got)
+	}
+	if len(q) != 3 || q.Rejected() != 2 {
+		t.Fatalf("quarantined %v auctions, rejected %v, want 3 and 2", len(q), q.Rejected())
+	}
+	for i, id := range []int{5, 6} {
+		if q[i].Auction.ID != id || q[i].Reason == "" || q[i].Kept {
+			t.Errorf("quarantined %+v, want rejected auction %v with a reason", q[i], id)
+		}
+	}
+	if got := q[2]; got.Auction.ID != 7 || !got.Kept || !strings.Contains(got.Reason, "FOREVER") {
+		t.Errorf("quarantined %+v, want kept auction 7 with its time left", got)
+	}
+}
+
+func TestGetAllAuctionsRetail(t *testing.T) {
+	fixtures := testFixtures()
+	fixtures.Auctions[_zuljinID] = append(fixtures.Auctions[_zuljinID],
+		wowapiclient.Auction{ID: 5, ItemID: 19019, Quantity: 0, Buyout: 5000000, TimeLeft: wowapiclient.TimeLeftLong},
+	)
+	s := wowapitest.NewServer(fixtures)
+	defer s.Close()
+
+	var q wowapiclient.Quarantine
+	auctions, err := newTestClient(t, s).GetAllAuctions(_zuljinID, wowapiclient.WithQuarantine(&q))
+	if err != nil {
+		t.Fatalf("GetAllAuctions() error = %v", err)
+	}
+	if len(auctions) != 2 || q.Rejected() != 1 {
+		t.Errorf("GetAllAuctions() got %v auctions, rejected %v, want 2 and 1", len(auctions), q.Rejected())
+	}
+}
+
+func TestServerCommodities(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	resp := struct {
+		Auctions []struct {
+			Quantity  int `json:"quantity"`
+			UnitPrice int `json:"unit_price"`
+		} `json:"auctions"`
+	}{}
+	if err := newTestClient(t, s).FollowHref(s.URL+"/data/wow/auctions/commodities?namespace=dynamic-us", &resp); err != nil {
+		t.Fatalf("FollowHref() error = %v", err)
+	}
+	if len(resp.Auctions) != 2 {
+		t.Fatalf("got %v commodities, want 2", len(resp.Auctions))
+	}
+	if got := resp.Auctions[0]; got.Quantity != 200 || got.UnitPrice != 150 {
+		t.Errorf("commodity = %+v, want 200 at 150", got)
+	}
+}
+
+func TestItemQualityAtLeast(t *testing.T) {
+	tests := []struct {
+		q, min wowapiclient.ItemQuality
+		want   bool
+	}{
+		{q: wowapiclient.QualityRare, min: wowapiclient.QualityUncommon, want: true},
+		{q: wowapiclient.QualityRare, min: wowapiclient.QualityRare, want: true},
+		{q: wowapiclient.QualityCommon, min: wowapiclient.QualityRare, want: false},
+		{q: "MYTHIC", min: wowapiclient.QualityPoor, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.q.AtLeast(tt.min); got != tt.want {
+			t.Errorf("%v.AtLeast(%v) = %v, want %v", tt.q, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestGetItem(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	c := newTestClient(t, s, wowapiclient.WithDefaultLocale(wowapiclient.LocaleEsMX))
+
+	item, err := c.GetItemDetail(_linenCloth)
+	if err != nil {
+		t.Fatalf("GetItemDetail() error = %v", err)
+	}
+	if got := item.Name.Get(wowapiclient.LocaleEsMX); got != "Paño de lino" {
+		t.Errorf("item name = %q, want Paño de lino", got)
+	}
+	if item.ItemClass != "Tradeskill" || item.ItemSubclassID != 5 || item.SellPrice != 13 || item.Quality != wowapiclient.QualityCommon || !item.IsStackable {
+		t.Errorf("GetItemDetail() = %+v", item)
+	}
+
+	_, err = c.GetItem(_missing)
+	if !wowapiclient.IsNotFound(err) {
+		t.Errorf("GetItem(%v) error = %v, want not found", _missing, err)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	// the fake server, like the API, only serves items in the static namespace
+	_, err := c.GetItem(_linenCloth, wowapiclient.WithNamespace(wowapiclient.NamespaceDynamic))
+	if !wowapiclient.IsNotFound(err) {
+		t.Errorf("GetItem() in the dynamic namespace error = %v, want not found", err)
+	}
+
+	c = newTestClient(t, s, wowapiclient.WithFlavor(wowapiclient.FlavorClassic))
+	if _, err := c.GetItem(_linenCloth); err != nil {
+		t.Errorf("GetItem() in the classic static namespace error = %v", err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		fault      wowapitest.Fault
+		wantStatus int
+	}{
+		{name: "too many requests", fault: wowapitest.Fault{StatusCode: http.StatusTooManyRequests}, wantStatus: http.StatusTooManyRequests},
+		{name: "server error", fault: wowapitest.Fault{StatusCode: http.StatusServiceUnavailable}, wantStatus: http.StatusServiceUnavailable},
+		{name: "malformed json", fault: wowapitest.Fault{MalformedJSON: true}},
+		{name: "latency", fault: wowapitest.Fault{Latency: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := wowapitest.NewServer(testFixtures())
+			defer s.Close()
+
+			httpClient, err := s.HTTPClient(context.Background())
+			if err != nil {
+				t.Fatalf("HTTPClient() error = %v", err)
+			}
+			httpClient.Timeout = 100 * time.Millisecond
+			c := wowapiclient.NewWOWAPIClient(httpClient, "us", wowapiclient.WithBaseURL(s.BaseURL()))
+
+			s.AddFault("/data/wow/connected-realm/61/auctions", tt.fault)
+			_, err = c.GetAuctions(_zuljinID)
+			if err == nil {
+				t.Fatal("GetAuctions() error = nil")
+			}
+			if tt.wantStatus != 0 {
+				se, ok := errors.Cause(err).(*wowapiclient.StatusError)
+				if !ok || se.StatusCode != tt.wantStatus {
+					t.Errorf("GetAuctions() error = %v, want status %v", err, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestFaultCount(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	s.AddFault("", wowapitest.Fault{StatusCode: http.StatusInternalServerError, Count: 1})
+	if _, err := c.GetAuctions(_zuljinID); err == nil {
+		t.Error("first GetAuctions() error = nil")
+	}
+	if _, err := c.GetAuctions(_zuljinID); err != nil {
+		t.Errorf("second GetAuctions() error = %v", err)
+	}
+	if got := s.Requests("/data/wow/connected-realm/61/auctions"); got != 2 {
+		t.Errorf("Requests() = %v, want 2", got)
+	}
+}
+
+func TestAuctionPrices(t *testing.T) {
+	tests := []struct {
+		name          string
+		auction       wowapiclient.Auction
+		wantUnit      int
+		wantTotal     int
+		wantBidOnly   bool
+		wantHasBuyout bool
+	}{
+		{
+			name:          "commodity",
+			auction:       wowapiclient.Auction{Quantity: 20, UnitPrice: 75},
+			wantUnit:      75,
+			wantTotal:     1500,
+			wantHasBuyout: true,
+		},
+		{
+			name:          "stack buyout",
+			auction:       wowapiclient.Auction{Quantity: 3, Buyout: 100, Bid: 50},
+			wantUnit:      33,
+			wantTotal:     100,
+			wantHasBuyout: true,
+		},
+		{
+			name:        "bid only",
+			auction:     wowapiclient.Auction{Quantity: 2, Bid: 50},
+			wantUnit:    25,
+			wantTotal:   50,
+			wantBidOnly: true,
+		},
+		{
+			name:          "no quantity",
+			auction:       wowapiclient.Auction{Buyout: 100},
+			wantUnit:      0,
+			wantTotal:     100,
+			wantHasBuyout: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.auction
+			if got := a.EffectiveUnitPrice(); got != tt.wantUnit {
+				t.Errorf("EffectiveUnitPrice() = %v, want %v", got, tt.wantUnit)
+			}
+			if got := a.EffectiveTotal(); got != tt.wantTotal {
+				t.Errorf("EffectiveTotal() = %v, want %v", got, tt.wantTotal)
+			}
+			if got := a.IsBidOnly(); got != tt.wantBidOnly {
+				t.Errorf("IsBidOnly() = %v, want %v", got, tt.wantBidOnly)
+			}
+			if got := a.HasBuyout(); got != tt.wantHasBuyout {
+				t.Errorf("HasBuyout() = %v, want %v", got, tt.wantHasBuyout)
+			}
+		})
+	}
+}
+
+func TestTimeLeftUnmarshalJSON(t *testing.T) {
+	var tl wowapiclient.TimeLeft
+	if err := json.Unmarshal([]byte(`"VERY_LONG"`), &tl); err != nil || tl != wowapiclient.TimeLeftVeryLong {
+		t.Errorf("Unmarshal(VERY_LONG) = %v, %v", tl, err)
+	}
+	if err := json.Unmarshal([]byte(`"FOREVER"`), &tl); err == nil {
+		t.Error("Unmarshal(FOREVER) error = nil")
+	}
+}
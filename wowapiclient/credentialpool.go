@@ -0,0 +1,230 @@
+package wowapiclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// _defaultRequestsPerHour is the hourly quota of a Battle.net API client.
+	_defaultRequestsPerHour = 36000
+
+	// _maxCredentialFailures is how many 401 or 429 responses in a row take a credential out of
+	// rotation.
+	_maxCredentialFailures = 3
+	// _credentialCooldown is how long a credential stays out of rotation.
+	_credentialCooldown = time.Hour
+)
+
+// ErrNoCredentials is returned by a CredentialPool when every credential is out of rotation.
+var ErrNoCredentials = errors.New("no credentials in rotation")
+
+// WithRequestsPerHour sets the rate budget of each credential in a CredentialPool, 36000 by default. n
+// must be positive.
+func WithRequestsPerHour(n int) HTTPClientOption {
+	return func(o *httpClientOptions) {
+		o.requestsPerHour = n
+	}
+}
+
+// CredentialPool is an http.RoundTripper which spreads API requests over several credentials. Each
+// credential has an hourly rate budget, and requests go to the credential with the most budget left,
+// waiting for budget when every credential has used theirs. A credential which is answered with 401
+// Unauthorized or 429 Too Many Requests, or which cannot fetch a new token, several times in a row is
+// taken out of rotation for an hour, and requests rejected with either are retried with another
+// credential.
+//
+// With WithTokenCache each credential's token is cached apart, see WithTokenCache.
+type CredentialPool struct {
+	perHour float64
+	now     func() time.Time
+
+	mu          sync.Mutex
+	credentials []*pooledCredential
+}
+
+type pooledCredential struct {
+	clientID  string
+	tokens    *cachingTokenSource
+	transport http.RoundTripper
+
+	budget       float64
+	refilled     time.Time
+	failures     int
+	ejectedUntil time.Time
+}
+
+// NewCredentialPool verifies each of secrets and pools them. Credentials which cannot complete the
+// oauth2 flow start out of rotation, it is an error if none of them can.
+func NewCredentialPool(ctx context.Context, secrets []OAuth2Secrets, region string, opts ...HTTPClientOption) (*CredentialPool, error) {
+	o := newHTTPClientOptions(region, opts)
+	p := &CredentialPool{
+		perHour: float64(o.requestsPerHour),
+		now:     time.Now,
+	}
+
+	var lastErr error
+	for _, s := range secrets {
+		tokens := o.tokenSource(ctx, s)
+		c := &pooledCredential{
+			clientID:  s.ClientID,
+			tokens:    tokens,
+			transport: o.authTransport(tokens),
+			budget:    p.perHour,
+			refilled:  p.now(),
+		}
+		if _, err := tokens.Token(); err != nil {
+			lastErr = errors.Wrapf(err, "failed to perform 2 legged oauth2 client auth for %v", s.ClientID)
+			c.ejectedUntil = p.now().Add(_credentialCooldown)
+		}
+		p.credentials = append(p.credentials, c)
+	}
+
+	if len(p.InRotation()) == 0 {
+		if lastErr == nil {
+			lastErr = ErrNoCredentials
+		}
+		return nil, lastErr
+	}
+	return p, nil
+}
+
+// Client creates an HTTP Client which makes requests through the pool.
+func (p *CredentialPool) Client() *http.Client {
+	return &http.Client{Transport: p}
+}
+
+// InRotation returns the client IDs of the credentials currently in rotation.
+func (p *CredentialPool) InRotation() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var ids []string
+	for _, c := range p.credentials {
+		if !now.Before(c.ejectedUntil) {
+			ids = append(ids, c.clientID)
+		}
+	}
+	return ids
+}
+
+// RoundTrip implements http.RoundTripper.
+func (p *CredentialPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	tried := make(map[*pooledCredential]bool)
+	var (
+		resp     *http.Response
+		tokenErr error
+	)
+	for {
+		c, err := p.acquire(req.Context(), tried)
+		if err != nil {
+			// every credential was tried, so return the last rejection
+			if resp != nil {
+				return resp, nil
+			}
+			if err == ErrNoCredentials && tokenErr != nil {
+				return nil, tokenErr
+			}
+			return nil, err
+		}
+		tried[c] = true
+
+		// A credential revoked since the pool was created cannot fetch a new token once its current one
+		// is rejected. The request has not been sent, so it is a rejection retried with another
+		// credential whether or not it has a body.
+		if _, err := c.tokens.Token(); err != nil {
+			p.report(c, http.StatusUnauthorized)
+			tokenErr = errors.Wrapf(err, "failed to get an oauth2 token for %v", c.clientID)
+			continue
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+			resp = nil
+		}
+		resp, err = c.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		rejected := p.report(c, resp.StatusCode)
+
+		// requests with a body cannot be safely sent twice
+		if !rejected || req.Body != nil {
+			return resp, nil
+		}
+	}
+}
+
+// acquire takes one request from the budget of the credential in rotation, and not in exclude, with the
+// most budget left, waiting for budget if needed.
+func (p *CredentialPool) acquire(ctx context.Context, exclude map[*pooledCredential]bool) (*pooledCredential, error) {
+	for {
+		p.mu.Lock()
+		now := p.now()
+		var best *pooledCredential
+		for _, c := range p.credentials {
+			if exclude[c] || now.Before(c.ejectedUntil) {
+				continue
+			}
+			p.refill(c, now)
+			if best == nil || c.budget > best.budget {
+				best = c
+			}
+		}
+		if best == nil {
+			p.mu.Unlock()
+			return nil, ErrNoCredentials
+		}
+		if best.budget >= 1 {
+			best.budget--
+			p.mu.Unlock()
+			return best, nil
+		}
+		wait := time.Duration((1 - best.budget) / p.perHour * float64(time.Hour))
+		p.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// refill adds the budget earned since c was last refilled.
+func (p *CredentialPool) refill(c *pooledCredential, now time.Time) {
+	c.budget += now.Sub(c.refilled).Hours() * p.perHour
+	if c.budget > p.perHour {
+		c.budget = p.perHour
+	}
+	c.refilled = now
+}
+
+// report records the response to a request made with c, taking c out of rotation after too many
+// rejections. It returns true if the request was rejected.
+func (p *CredentialPool) report(c *pooledCredential, statusCode int) bool {
+	if statusCode != http.StatusUnauthorized && statusCode != http.StatusTooManyRequests {
+		p.mu.Lock()
+		c.failures = 0
+		p.mu.Unlock()
+		return false
+	}
+
+	if statusCode == http.StatusUnauthorized {
+		c.tokens.invalidate()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c.failures++
+	if c.failures >= _maxCredentialFailures {
+		c.failures = 0
+		c.ejectedUntil = p.now().Add(_credentialCooldown)
+	}
+	return true
+}
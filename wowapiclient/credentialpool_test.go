@@ -0,0 +1,148 @@
+package wowapiclient_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+)
+
+func newTestPool(t *testing.T, s *wowapitest.Server, secrets []wowapiclient.OAuth2Secrets, opts ...wowapiclient.HTTPClientOption) *wowapiclient.CredentialPool {
+	t.Helper()
+	for _, sec := range secrets {
+		if sec.ClientSecret != "bad" {
+			s.AddClient(sec.ClientID, sec.ClientSecret)
+		}
+	}
+	opts = append([]wowapiclient.HTTPClientOption{wowapiclient.WithTokenURL(s.TokenURL())}, opts...)
+	p, err := wowapiclient.NewCredentialPool(context.Background(), secrets, "us", opts...)
+	if err != nil {
+		t.Fatalf("NewCredentialPool() error = %v", err)
+	}
+	return p
+}
+
+func TestCredentialPoolDistributesRequests(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	p := newTestPool(t, s, []wowapiclient.OAuth2Secrets{
+		{ClientID: "a", ClientSecret: "a-secret"},
+		{ClientID: "b", ClientSecret: "b-secret"},
+	})
+	c := wowapiclient.NewWOWAPIClient(p.Client(), "us", wowapiclient.WithBaseURL(s.BaseURL()))
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.GetAuctions(_zuljinID); err != nil {
+			t.Fatalf("GetAuctions() error = %v", err)
+		}
+	}
+	for _, id := range []string{"a", "b"} {
+		if got := s.ClientRequests(id); got != 5 {
+			t.Errorf("ClientRequests(%v) = %v, want 5", id, got)
+		}
+	}
+}
+
+func TestCredentialPoolEjectsRejectedCredentials(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	p := newTestPool(t, s, []wowapiclient.OAuth2Secrets{
+		{ClientID: "a", ClientSecret: "a-secret"},
+		{ClientID: "b", ClientSecret: "b-secret"},
+	})
+	c := wowapiclient.NewWOWAPIClient(p.Client(), "us", wowapiclient.WithBaseURL(s.BaseURL()))
+
+	s.AddFault("", wowapitest.Fault{StatusCode: http.StatusTooManyRequests, ClientID: "a"})
+	for i := 0; i < 10; i++ {
+		// rejected requests are retried with the other credential
+		if _, err := c.GetAuctions(_zuljinID); err != nil {
+			t.Fatalf("GetAuctions() error = %v", err)
+		}
+	}
+
+	if got := p.InRotation(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("InRotation() = %v, want [b]", got)
+	}
+	if got := s.ClientRequests("a"); got != 3 {
+		t.Errorf("ClientRequests(a) = %v, want 3", got)
+	}
+}
+
+func TestCredentialPoolEjectsRevokedCredentials(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	p := newTestPool(t, s, []wowapiclient.OAuth2Secrets{
+		{ClientID: "a", ClientSecret: "a-secret"},
+		{ClientID: "b", ClientSecret: "b-secret"},
+	})
+	c := wowapiclient.NewWOWAPIClient(p.Client(), "us", wowapiclient.WithBaseURL(s.BaseURL()))
+
+	// a's token is rejected, and every token it asks for after that is refused
+	s.RevokeClient("a")
+	for i := 0; i < 10; i++ {
+		if _, err := c.GetAuctions(_zuljinID); err != nil {
+			t.Fatalf("GetAuctions() error = %v", err)
+		}
+	}
+
+	if got := p.InRotation(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("InRotation() = %v, want [b]", got)
+	}
+	if got := s.ClientRequests("b"); got != 10 {
+		t.Errorf("ClientRequests(b) = %v, want 10", got)
+	}
+}
+
+func TestCredentialPoolBudget(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	p := newTestPool(t, s, []wowapiclient.OAuth2Secrets{{ClientID: "a", ClientSecret: "a-secret"}}, wowapiclient.WithRequestsPerHour(1))
+
+	do := func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodGet, s.URL+"/data/wow/connected-realm/61/auctions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Battlenet-Namespace", "dynamic-us")
+		resp, err := p.Client().Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	if err := do(context.Background()); err != nil {
+		t.Fatalf("first request error = %v", err)
+	}
+
+	// the budget is used up, so the next request waits an hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := do(ctx); err == nil {
+		t.Error("second request error = nil")
+	}
+}
+
+func TestNewCredentialPool(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	p := newTestPool(t, s, []wowapiclient.OAuth2Secrets{
+		{ClientID: "a", ClientSecret: "bad"},
+		{ClientID: "b", ClientSecret: "b-secret"},
+	})
+	if got := p.InRotation(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("InRotation() = %v, want [b]", got)
+	}
+
+	_, err := wowapiclient.NewCredentialPool(context.Background(), []wowapiclient.OAuth2Secrets{
+		{ClientID: "c", ClientSecret: "bad"},
+	}, "us", wowapiclient.WithTokenURL(s.TokenURL()))
+	if err == nil {
+		t.Error("NewCredentialPool() with only bad credentials error = nil")
+	}
+}
@@ -0,0 +1,71 @@
+package wowapiclient
+
+import "fmt"
+
+// GameFlavor is a version of the game with its own realms, items and auction houses.
+type GameFlavor string
+
+const (
+	// FlavorRetail is the current version of the game.
+	FlavorRetail GameFlavor = "retail"
+	// FlavorClassic is WoW Classic, including its progression realms.
+	FlavorClassic GameFlavor = "classic"
+	// FlavorClassicEra is WoW Classic Era, the realms which stay on the original game.
+	FlavorClassicEra GameFlavor = "classic_era"
+)
+
+// WithFlavor sets the game flavor the client calls, FlavorRetail by default. Endpoints are called in
+// the flavor's variant of their namespace.
+func WithFlavor(f GameFlavor) ClientOption {
+	return func(c *WOWAPIClient) {
+		c.flavor = f
+	}
+}
+
+// Namespace returns the flavor's variant of a retail namespace.
+func (f GameFlavor) Namespace(n Namespace) Namespace {
+	switch f {
+	case FlavorClassic:
+		return Namespace(fmt.Sprintf("%s-classic", n))
+	case FlavorClassicEra:
+		return Namespace(fmt.Sprintf("%s-classic1x", n))
+	default:
+		return n
+	}
+}
+
+// Faction is the faction an auction house belongs to. Retail auction houses are shared by every
+// faction and have no Faction.
+type Faction string
+
+const (
+	// FactionAlliance is the Alliance auction house.
+	FactionAlliance Faction = "ALLIANCE"
+	// FactionHorde is the Horde auction house.
+	FactionHorde Faction = "HORDE"
+	// FactionNeutral is the neutral (Booty Bay, Everlook, Gadgetzan) auction house.
+	FactionNeutral Faction = "NEUTRAL"
+)
+
+// _auctionHouseFactions maps the IDs of the classic auction houses to their faction.
+var _auctionHouseFactions = map[int]Faction{
+	2: FactionAlliance,
+	6: FactionHorde,
+	7: FactionNeutral,
+}
+
+// AuctionHouse is one of the per-faction auction houses of a classic connected realm.
+type AuctionHouse struct {
+	ID      int
+	Name    string
+	Faction Faction
+}
+
+type auctionHouseIndexResponse struct {
+	responseLinks
+	Auctions []struct {
+		Key  Link            `json:"key"`
+		Name LocalizedString `json:"name"`
+		ID   int             `json:"id"`
+	} `json:"auctions"`
+}
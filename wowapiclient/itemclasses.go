@@ -0,0 +1,100 @@
+package wowapiclient
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ItemClass is a top level item category such as "Trade Goods" or "Armor".
+type ItemClass struct {
+	ID   int
+	Name LocalizedString
+	// Subclasses is only set by GetItemClass.
+	Subclasses []ItemSubclass
+}
+
+// ItemSubclass is a category within an ItemClass such as "Herb" or "Cloth".
+type ItemSubclass struct {
+	ClassID int
+	ID      int
+	Name    LocalizedString
+	// VerboseName is only set by GetItemSubclass, and only for subclasses which have one.
+	VerboseName LocalizedString
+}
+
+// GetItemClasses gets every item class, without their subclasses.
+func (c *WOWAPIClient) GetItemClasses(opts ...CallOption) ([]ItemClass, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := itemClassIndexResponse{}
+	if err := c.callAPI("/data/wow/item-class/index", url.Values{}, &resp, o); err != nil {
+		return nil, err
+	}
+
+	classes := make([]ItemClass, 0, len(resp.ItemClasses))
+	for _, ic := range resp.ItemClasses {
+		classes = append(classes, ItemClass{
+			ID:   ic.ID,
+			Name: ic.Name.inLocale(o.locale),
+		})
+	}
+	return classes, nil
+}
+
+// GetItemClass gets an item class and its subclasses.
+func (c *WOWAPIClient) GetItemClass(id int, opts ...CallOption) (ItemClass, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := itemClassResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/item-class/%v", id), url.Values{}, &resp, o); err != nil {
+		return ItemClass{}, err
+	}
+
+	ic := ItemClass{
+		ID:         resp.ClassID,
+		Name:       resp.Name.inLocale(o.locale),
+		Subclasses: make([]ItemSubclass, 0, len(resp.ItemSubclasses)),
+	}
+	for _, sc := range resp.ItemSubclasses {
+		ic.Subclasses = append(ic.Subclasses, ItemSubclass{
+			ClassID: resp.ClassID,
+			ID:      sc.ID,
+			Name:    sc.Name.inLocale(o.locale),
+		})
+	}
+	return ic, nil
+}
+
+// GetItemSubclass gets a single item subclass.
+func (c *WOWAPIClient) GetItemSubclass(classID, subclassID int, opts ...CallOption) (ItemSubclass, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := itemSubclassResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/item-class/%v/item-subclass/%v", classID, subclassID), url.Values{}, &resp, o); err != nil {
+		return ItemSubclass{}, err
+	}
+
+	return ItemSubclass{
+		ClassID:     resp.ClassID,
+		ID:          resp.SubclassID,
+		Name:        resp.DisplayName.inLocale(o.locale),
+		VerboseName: resp.VerboseName.inLocale(o.locale),
+	}, nil
+}
+
+type itemClassIndexResponse struct {
+	responseLinks
+	ItemClasses []itemClass `json:"item_classes"`
+}
+
+type itemClassResponse struct {
+	responseLinks
+	ClassID        int             `json:"class_id"`
+	Name           LocalizedString `json:"name"`
+	ItemSubclasses []itemClass     `json:"item_subclasses"`
+}
+
+type itemSubclassResponse struct {
+	responseLinks
+	ClassID     int             `json:"class_id"`
+	SubclassID  int             `json:"subclass_id"`
+	DisplayName LocalizedString `json:"display_name"`
+	VerboseName LocalizedString `json:"verbose_name"`
+}
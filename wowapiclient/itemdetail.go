@@ -0,0 +1,111 @@
+package wowapiclient
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ItemDetail is the full representation of an ingame item.
+type ItemDetail struct {
+	Item
+	Quality       ItemQuality
+	Level         int
+	RequiredLevel int
+	// InventoryType is the equipment slot of the item, such as "HEAD" or "NON_EQUIP".
+	InventoryType string
+	// PurchasePrice is the copper a vendor charges for PurchaseQuantity of the item.
+	PurchasePrice    int
+	PurchaseQuantity int
+	// SellPrice is the copper a vendor pays for a single item.
+	SellPrice int
+	// MaxCount is the API's max_count, the most of the item a character may have, or 0 if unlimited.
+	MaxCount     int
+	IsEquippable bool
+	IsStackable  bool
+	Binding      ItemBinding
+}
+
+// GetItemDetail gets an item with all of its details from the wow API with the given ID.
+func (c *WOWAPIClient) GetItemDetail(id int, opts ...CallOption) (ItemDetail, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := itemResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/item/%v", id), url.Values{}, &resp, o); err != nil {
+		return ItemDetail{}, err
+	}
+
+	return ItemDetail{
+		Item:             resp.toItem(o.locale),
+		Quality:          ItemQuality(resp.Quality.Type),
+		Level:            resp.Level,
+		RequiredLevel:    resp.RequiredLevel,
+		InventoryType:    resp.InventoryType.Type,
+		PurchasePrice:    resp.PurchasePrice,
+		PurchaseQuantity: resp.PurchaseQuantity,
+		SellPrice:        resp.SellPrice,
+		MaxCount:         resp.MaxCount,
+		IsEquippable:     resp.IsEquippable,
+		IsStackable:      resp.IsStackable,
+		Binding:          ItemBinding(resp.PreviewItem.Binding.Type),
+	}, nil
+}
+
+// ItemQuality is the rarity of an item.
+type ItemQuality string
+
+const (
+	// QualityPoor is a grey item.
+	QualityPoor ItemQuality = "POOR"
+	// QualityCommon is a white item.
+	QualityCommon ItemQuality = "COMMON"
+	// QualityUncommon is a green item.
+	QualityUncommon ItemQuality = "UNCOMMON"
+	// QualityRare is a blue item.
+	QualityRare ItemQuality = "RARE"
+	// QualityEpic is a purple item.
+	QualityEpic ItemQuality = "EPIC"
+	// QualityLegendary is an orange item.
+	QualityLegendary ItemQuality = "LEGENDARY"
+	// QualityArtifact is an artifact weapon.
+	QualityArtifact ItemQuality = "ARTIFACT"
+	// QualityHeirloom is an heirloom.
+	QualityHeirloom ItemQuality = "HEIRLOOM"
+)
+
+var _qualityRanks = map[ItemQuality]int{
+	QualityPoor:      0,
+	QualityCommon:    1,
+	QualityUncommon:  2,
+	QualityRare:      3,
+	QualityEpic:      4,
+	QualityLegendary: 5,
+	QualityArtifact:  6,
+	QualityHeirloom:  7,
+}
+
+// AtLeast returns true if q is the same or a higher quality than min. Unknown qualities are never at
+// least anything.
+func (q ItemQuality) AtLeast(min ItemQuality) bool {
+	rank, ok := _qualityRanks[q]
+	if !ok {
+		return false
+	}
+	return rank >= _qualityRanks[min]
+}
+
+// ItemBinding is when an item becomes soulbound.
+type ItemBinding string
+
+const (
+	// BindingNone means the item never binds, it is returned for items without a binding.
+	BindingNone ItemBinding = ""
+	// BindingOnAcquire means the item binds when picked up.
+	BindingOnAcquire ItemBinding = "ON_ACQUIRE"
+	// BindingOnEquip means the item binds when equipped.
+	BindingOnEquip ItemBinding = "ON_EQUIP"
+	// BindingOnUse means the item binds when used.
+	BindingOnUse ItemBinding = "ON_USE"
+	// BindingToAccount means the item binds to the account.
+	BindingToAccount ItemBinding = "TO_ACCOUNT"
+	// BindingQuest means the item is a quest item.
+	BindingQuest ItemBinding = "QUEST"
+)
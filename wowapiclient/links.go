@@ -0,0 +1,69 @@
+package wowapiclient
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ErrMalformedHref is the cause of errors from hrefs which are not API links.
+var ErrMalformedHref = errors.New("malformed href")
+
+// Link is a link from one API resource to another, for example the "key" of every item in an index.
+type Link struct {
+	Href string `json:"href"`
+}
+
+// ID gets the ID of the linked resource, see IDFromHref.
+func (l Link) ID() (int, error) {
+	return IDFromHref(l.Href)
+}
+
+// responseLinks are the links every API response starts with.
+type responseLinks struct {
+	Links map[string]Link `json:"_links"`
+}
+
+// IDFromHref gets the ID from the last path segment of an API link such as
+// "https://us.api.blizzard.com/data/wow/connected-realm/11?namespace=dynamic-us". Errors are caused by
+// ErrMalformedHref.
+func IDFromHref(href string) (int, error) {
+	u, err := url.Parse(href)
+	if err != nil || u.Path == "" {
+		return 0, errors.Wrapf(ErrMalformedHref, "failed to get ID from %q", href)
+	}
+
+	id, err := strconv.Atoi(path.Base(u.Path))
+	if err != nil || id < 0 {
+		return 0, errors.Wrapf(ErrMalformedHref, "failed to get ID from %q", href)
+	}
+	return id, nil
+}
+
+// FollowLink calls the API resource l links to and decodes it into target, see FollowHref.
+func (c *WOWAPIClient) FollowLink(l Link, target interface{}, opts ...CallOption) error {
+	return c.FollowHref(l.Href, target, opts...)
+}
+
+// FollowHref calls the API resource at href and decodes it into target. The resource is fetched from the
+// client's API host in the namespace from the href's query string, which may be overridden with
+// WithNamespace. Hrefs without a namespace are fetched in NamespaceDynamic.
+func (c *WOWAPIClient) FollowHref(href string, target interface{}, opts ...CallOption) error {
+	u, err := url.Parse(href)
+	if err != nil || u.Path == "" {
+		return errors.Wrapf(ErrMalformedHref, "failed to follow %q", href)
+	}
+
+	query := u.Query()
+	o := c.newCallOptions(NamespaceDynamic, nil)
+	o.hrefNamespace = query.Get("namespace")
+	for _, opt := range opts {
+		opt(&o)
+	}
+	query.Del("namespace")
+	query.Del("locale")
+
+	return c.callAPI(u.Path, query, target, o)
+}
@@ -0,0 +1,85 @@
+package wowapiclient_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+	"github.com/pkg/errors"
+)
+
+func TestIDFromHref(t *testing.T) {
+	tests := []struct {
+		href    string
+		want    int
+		wantErr bool
+	}{
+		{href: "https://us.api.blizzard.com/data/wow/connected-realm/11?namespace=dynamic-us", want: 11},
+		{href: "https://us.api.blizzard.com/data/wow/item/19019", want: 19019},
+		{href: "/data/wow/connected-realm/61", want: 61},
+		{href: "", wantErr: true},
+		{href: "https://us.api.blizzard.com/data/wow/connected-realm/index?namespace=dynamic-us", wantErr: true},
+		{href: "https://us.api.blizzard.com/data/wow/connected-realm/-1", wantErr: true},
+		{href: "%zz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.href, func(t *testing.T) {
+			got, err := wowapiclient.IDFromHref(tt.href)
+			if tt.wantErr {
+				if errors.Cause(err) != wowapiclient.ErrMalformedHref {
+					t.Errorf("IDFromHref() error = %v, want ErrMalformedHref", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("IDFromHref() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowHref(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	c, err := s.NewClient(context.Background())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var cr struct {
+		ID     int `json:"id"`
+		Realms []struct {
+			Slug           string            `json:"slug"`
+			ConnectedRealm wowapiclient.Link `json:"connected_realm"`
+		} `json:"realms"`
+	}
+	// the host of the href is ignored in favor of the client's
+	href := "https://us.api.blizzard.com/data/wow/connected-realm/61?namespace=dynamic-us"
+	if err := c.FollowHref(href, &cr); err != nil {
+		t.Fatalf("FollowHref() error = %v", err)
+	}
+	if cr.ID != _zuljinID || len(cr.Realms) != 1 || cr.Realms[0].Slug != "zuljin" {
+		t.Fatalf("FollowHref() = %+v", cr)
+	}
+
+	id, err := cr.Realms[0].ConnectedRealm.ID()
+	if err != nil || id != _zuljinID {
+		t.Errorf("ID() = %v, %v, want %v", id, err, _zuljinID)
+	}
+	if err := c.FollowLink(cr.Realms[0].ConnectedRealm, &cr); err != nil {
+		t.Errorf("FollowLink() error = %v", err)
+	}
+
+	err = c.FollowHref(href, &cr, wowapiclient.WithNamespace(wowapiclient.NamespaceStatic))
+	if !wowapiclient.IsNotFound(err) {
+		t.Errorf("FollowHref() in the static namespace error = %v, want not found", err)
+	}
+
+	err = c.FollowHref("https://us.api.blizzard.com/data/wow/connected-realm/61?namespace=dynamic-eu", &cr)
+	if !wowapiclient.IsNotFound(err) {
+		t.Errorf("FollowHref() in the eu namespace error = %v, want not found", err)
+	}
+}
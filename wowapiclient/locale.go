@@ -0,0 +1,117 @@
+package wowapiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Locale is a locale supported by the API.
+type Locale string
+
+const (
+	// LocaleAll requests strings in every locale rather than a single one.
+	LocaleAll Locale = "all"
+
+	// LocaleEnUS is English (United States).
+	LocaleEnUS Locale = "en_US"
+	// LocaleEsMX is Spanish (Mexico).
+	LocaleEsMX Locale = "es_MX"
+	// LocalePtBR is Portuguese (Brazil).
+	LocalePtBR Locale = "pt_BR"
+	// LocaleEnGB is English (Great Britain).
+	LocaleEnGB Locale = "en_GB"
+	// LocaleEsES is Spanish (Spain).
+	LocaleEsES Locale = "es_ES"
+	// LocaleFrFR is French.
+	LocaleFrFR Locale = "fr_FR"
+	// LocaleRuRU is Russian.
+	LocaleRuRU Locale = "ru_RU"
+	// LocaleDeDE is German.
+	LocaleDeDE Locale = "de_DE"
+	// LocalePtPT is Portuguese (Portugal).
+	LocalePtPT Locale = "pt_PT"
+	// LocaleItIT is Italian.
+	LocaleItIT Locale = "it_IT"
+	// LocaleKoKR is Korean.
+	LocaleKoKR Locale = "ko_KR"
+	// LocaleZhTW is Chinese (Traditional).
+	LocaleZhTW Locale = "zh_TW"
+	// LocaleZhCN is Chinese (Simplified).
+	LocaleZhCN Locale = "zh_CN"
+)
+
+// WithLocale overrides the locale an API call is made in. LocaleAll returns every locale.
+func WithLocale(l Locale) CallOption {
+	return func(o *callOptions) {
+		o.locale = l
+	}
+}
+
+// _unknownLocale holds a plain string from the API until the client records which locale it was
+// requested in.
+const _unknownLocale Locale = ""
+
+// LocalizedString is a string from the API in one or more locales. The API returns a plain string when
+// a single locale is requested and a map of every locale otherwise.
+type LocalizedString map[Locale]string
+
+// UnmarshalJSON unmarshals a LocalizedString from either a json string or an object of locales.
+func (s *LocalizedString) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*s = nil
+		return nil
+	}
+
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*s = LocalizedString{_unknownLocale: single}
+		return nil
+	}
+
+	all := map[Locale]string{}
+	if err := json.Unmarshal(b, &all); err != nil {
+		return errors.Wrapf(err, "cannot unmarshal %s as LocalizedString", b)
+	}
+	*s = all
+	return nil
+}
+
+// Get returns the string in the given locale, or an empty string if it is not available.
+func (s LocalizedString) Get(l Locale) string {
+	return s[l]
+}
+
+// String returns the string if there is only one locale, otherwise the en_US string.
+func (s LocalizedString) String() string {
+	if len(s) == 1 {
+		for _, v := range s {
+			return v
+		}
+	}
+	if v, ok := s[LocaleEnUS]; ok {
+		return v
+	}
+
+	// fall back to something stable
+	locales := make([]string, 0, len(s))
+	for l := range s {
+		locales = append(locales, string(l))
+	}
+	sort.Strings(locales)
+	if len(locales) == 0 {
+		return ""
+	}
+	return s[Locale(locales[0])]
+}
+
+// inLocale records that a plain string was requested in the locale l.
+func (s LocalizedString) inLocale(l Locale) LocalizedString {
+	v, ok := s[_unknownLocale]
+	if !ok || l == LocaleAll {
+		return s
+	}
+	return LocalizedString{l: v}
+}
@@ -0,0 +1,61 @@
+package wowapiclient
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ItemMedia is the media, such as the icon, of an item.
+type ItemMedia struct {
+	ItemID int
+	Assets []MediaAsset
+}
+
+// MediaAsset is a single media file hosted on Blizzard's CDN.
+type MediaAsset struct {
+	// Key is the kind of asset, for example "icon".
+	Key        string
+	URL        string
+	FileDataID int
+}
+
+// Icon returns the icon asset of the item, if it has one.
+func (m ItemMedia) Icon() (MediaAsset, bool) {
+	for _, a := range m.Assets {
+		if a.Key == "icon" {
+			return a, true
+		}
+	}
+	return MediaAsset{}, false
+}
+
+// GetItemMedia gets the media of the item with the given ID.
+func (c *WOWAPIClient) GetItemMedia(id int, opts ...CallOption) (ItemMedia, error) {
+	resp := mediaResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/media/item/%v", id), url.Values{}, &resp, c.newCallOptions(NamespaceStatic, opts)); err != nil {
+		return ItemMedia{}, err
+	}
+
+	m := ItemMedia{
+		ItemID: id,
+		Assets: make([]MediaAsset, 0, len(resp.Assets)),
+	}
+	for _, a := range resp.Assets {
+		m.Assets = append(m.Assets, MediaAsset{
+			Key:        a.Key,
+			URL:        a.Value,
+			FileDataID: a.FileDataID,
+		})
+	}
+	return m, nil
+}
+
+type mediaResponse struct {
+	responseLinks
+	ID     int `json:"id"`
+	Assets []struct {
+		Key        string `json:"key"`
+		Value      string `json:"value"`
+		FileDataID int    `json:"file_data_id"`
+	} `json:"assets"`
+}
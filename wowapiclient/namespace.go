@@ -0,0 +1,58 @@
+package wowapiclient
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Namespace is a Battle.net API namespace without its region. Every endpoint lives in exactly one
+// namespace, static game data such as items in NamespaceStatic, data which changes such as auctions and
+// realms in NamespaceDynamic, and character data in NamespaceProfile.
+type Namespace string
+
+const (
+	// NamespaceStatic is for game data which only changes with patches.
+	NamespaceStatic Namespace = "static"
+	// NamespaceDynamic is for game data which changes between patches.
+	NamespaceDynamic Namespace = "dynamic"
+	// NamespaceProfile is for character and account data.
+	NamespaceProfile Namespace = "profile"
+	// NamespaceStaticClassic is NamespaceStatic for WoW Classic.
+	NamespaceStaticClassic Namespace = "static-classic"
+	// NamespaceDynamicClassic is NamespaceDynamic for WoW Classic.
+	NamespaceDynamicClassic Namespace = "dynamic-classic"
+	// NamespaceProfileClassic is NamespaceProfile for WoW Classic.
+	NamespaceProfileClassic Namespace = "profile-classic"
+	// NamespaceStaticClassicEra is NamespaceStatic for WoW Classic Era.
+	NamespaceStaticClassicEra Namespace = "static-classic1x"
+	// NamespaceDynamicClassicEra is NamespaceDynamic for WoW Classic Era.
+	NamespaceDynamicClassicEra Namespace = "dynamic-classic1x"
+	// NamespaceProfileClassicEra is NamespaceProfile for WoW Classic Era.
+	NamespaceProfileClassicEra Namespace = "profile-classic1x"
+)
+
+// ForRegion returns the full namespace as sent to the API, for example "static-us".
+func (n Namespace) ForRegion(region string) string {
+	return fmt.Sprintf("%s-%s", n, region)
+}
+
+// WithNamespace overrides the namespace an API call is made in, regardless of the client's GameFlavor.
+func WithNamespace(n Namespace) CallOption {
+	return func(o *callOptions) {
+		o.namespace = n
+		o.hrefNamespace = ""
+	}
+}
+
+// fullNamespace returns the namespace to send to the API, which is the namespace of the followed link
+// when there is one.
+func (o callOptions) fullNamespace(region string) string {
+	if o.hrefNamespace != "" {
+		return o.hrefNamespace
+	}
+	return o.namespace.ForRegion(region)
+}
+
+func addNamespace(req *http.Request, namespace string) {
+	req.Header.Add("Battlenet-Namespace", namespace)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/ZymoticB/wowauctiondata/blobstore"
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
@@ -18,21 +20,117 @@ type OAuth2Secrets struct {
 	ClientSecret string
 }
 
-// GetHTTPClient sets up an HTTP Client which will automatically refresh a client oauth2
-// token.
-func GetHTTPClient(ctx context.Context, secrets OAuth2Secrets, region string) (*http.Client, error) {
+// HTTPClientOption configures the HTTP Client from GetHTTPClient or a CredentialPool.
+type HTTPClientOption func(*httpClientOptions)
+
+type httpClientOptions struct {
+	region          string
+	tokenURL        string
+	transport       http.RoundTripper
+	tokenStore      blobstore.Store
+	tokenName       string
+	requestsPerHour int
+}
+
+func newHTTPClientOptions(region string, opts []HTTPClientOption) httpClientOptions {
+	o := httpClientOptions{
+		region:          region,
+		tokenURL:        fmt.Sprintf(_apiTokenURLFormat, region),
+		requestsPerHour: _defaultRequestsPerHour,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// tokenSource creates the token source for secrets, cached in the token store under a name for the
+// region and client.
+func (o httpClientOptions) tokenSource(ctx context.Context, secrets OAuth2Secrets) *cachingTokenSource {
+	if o.transport != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: o.transport})
+	}
+
 	oauth2ClientConfig := clientcredentials.Config{
 		ClientID:     secrets.ClientID,
 		ClientSecret: secrets.ClientSecret,
-		TokenURL:     fmt.Sprintf(_apiTokenURLFormat, region),
+		TokenURL:     o.tokenURL,
 		AuthStyle:    oauth2.AuthStyleInHeader,
 	}
 
+	return &cachingTokenSource{
+		ctx:   ctx,
+		fetch: oauth2ClientConfig.Token,
+		store: o.tokenStore,
+		name:  tokenCacheName(o.tokenName, o.region, secrets.ClientID),
+		now:   time.Now,
+	}
+}
+
+// authTransport authenticates requests with tokens from tokenSource. oauth2.NewClient would only ask
+// tokenSource for a new token once the current one has expired.
+func (o httpClientOptions) authTransport(tokenSource oauth2.TokenSource) http.RoundTripper {
+	return &oauth2.Transport{
+		Source: tokenSource,
+		Base:   o.transport,
+	}
+}
+
+// WithTokenURL fetches oauth2 tokens from tokenURL rather than the region's battle.net token URL.
+func WithTokenURL(tokenURL string) HTTPClientOption {
+	return func(o *httpClientOptions) {
+		o.tokenURL = tokenURL
+	}
+}
+
+// WithTransport makes both token and API requests through transport rather than
+// http.DefaultTransport, for example to go through an egress proxy.
+func WithTransport(transport http.RoundTripper) HTTPClientOption {
+	return func(o *httpClientOptions) {
+		o.transport = transport
+	}
+}
+
+// GetHTTPClient sets up an HTTP Client which will automatically refresh a client oauth2
+// token. A token is fetched, or loaded from the WithTokenCache store, up front to verify the
+// credentials and is then used by the client. A token the API rejects, such as a cached token which was
+// revoked before it expired, is dropped and the request retried once with a new token.
+func GetHTTPClient(ctx context.Context, secrets OAuth2Secrets, region string, opts ...HTTPClientOption) (*http.Client, error) {
+	o := newHTTPClientOptions(region, opts)
+	tokenSource := o.tokenSource(ctx, secrets)
+
 	// Get an initial token to verify the client can complete the oauth2 flow
-	_, err := oauth2ClientConfig.Token(ctx)
+	_, err := tokenSource.Token()
 	if err != nil {
+		tokenSource.invalidate()
 		return nil, errors.Wrap(err, "failed to perform 2 legged oauth2 client auth")
 	}
 
-	return oauth2ClientConfig.Client(ctx), nil
+	return &http.Client{Transport: &reauthTransport{
+		tokens: tokenSource,
+		auth:   o.authTransport(tokenSource),
+	}}, nil
+}
+
+// reauthTransport drops the token of a request answered with 401 Unauthorized and retries the request
+// once with a new token.
+type reauthTransport struct {
+	tokens *cachingTokenSource
+	auth   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *reauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.auth.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	t.tokens.invalidate()
+
+	// requests with a body cannot be safely sent twice
+	if req.Body != nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.auth.RoundTrip(req)
 }
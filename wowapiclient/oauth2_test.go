@@ -0,0 +1,183 @@
+package wowapiclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+func TestGetHTTPClientReusesVerificationToken(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	if _, err := c.GetAuctions(_zuljinID); err != nil {
+		t.Fatalf("GetAuctions() error = %v", err)
+	}
+	if got := s.TokenRequests(); got != 1 {
+		t.Errorf("TokenRequests() = %v, want 1", got)
+	}
+}
+
+func TestGetHTTPClientTokenCache(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	ctx := context.Background()
+	store := &recordingStore{Store: blobstore.NewMemory()}
+
+	newClient := func() *wowapiclient.WOWAPIClient {
+		httpClient, err := wowapiclient.GetHTTPClient(ctx, s.Secrets(), "us",
+			wowapiclient.WithTokenURL(s.TokenURL()),
+			wowapiclient.WithTokenCache(store, "token.json"),
+		)
+		if err != nil {
+			t.Fatalf("GetHTTPClient() error = %v", err)
+		}
+		return wowapiclient.NewWOWAPIClient(httpClient, "us", wowapiclient.WithBaseURL(s.BaseURL()))
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := newClient().GetAuctions(_zuljinID); err != nil {
+			t.Fatalf("GetAuctions() error = %v", err)
+		}
+	}
+	if got := s.TokenRequests(); got != 1 {
+		t.Errorf("TokenRequests() = %v, want 1", got)
+	}
+	name := store.names[0]
+	if !strings.HasPrefix(name, "token.json.us.") {
+		t.Errorf("cached token name = %q, want it keyed by region and client", name)
+	}
+
+	// a token about to expire is replaced before it is used
+	b, err := json.Marshal(&oauth2.Token{AccessToken: "expiring", Expiry: time.Now().Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, name, b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newClient().GetAuctions(_zuljinID); err != nil {
+		t.Fatalf("GetAuctions() error = %v", err)
+	}
+	if got := s.TokenRequests(); got != 2 {
+		t.Errorf("TokenRequests() = %v, want 2", got)
+	}
+
+	b, err = store.Get(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := oauth2.Token{}
+	if err := json.Unmarshal(b, &token); err != nil || token.AccessToken == "expiring" {
+		t.Errorf("cached token = %+v, %v, want a new token", token, err)
+	}
+}
+
+func TestGetHTTPClientTokenCacheKeyedByClient(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	s.AddClient("other", "other-secret")
+	store := blobstore.NewMemory()
+
+	for _, secrets := range []wowapiclient.OAuth2Secrets{s.Secrets(), {ClientID: "other", ClientSecret: "other-secret"}} {
+		if _, err := wowapiclient.GetHTTPClient(context.Background(), secrets, "us",
+			wowapiclient.WithTokenURL(s.TokenURL()),
+			wowapiclient.WithTokenCache(store, "token.json"),
+		); err != nil {
+			t.Fatalf("GetHTTPClient(%v) error = %v", secrets.ClientID, err)
+		}
+	}
+	// the token of one client is not reused by another
+	if got := s.TokenRequests(); got != 2 {
+		t.Errorf("TokenRequests() = %v, want 2", got)
+	}
+}
+
+func TestGetHTTPClientRevokedCachedToken(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+	store := blobstore.NewMemory()
+
+	newClient := func() *wowapiclient.WOWAPIClient {
+		httpClient, err := wowapiclient.GetHTTPClient(context.Background(), s.Secrets(), "us",
+			wowapiclient.WithTokenURL(s.TokenURL()),
+			wowapiclient.WithTokenCache(store, "token.json"),
+		)
+		if err != nil {
+			t.Fatalf("GetHTTPClient() error = %v", err)
+		}
+		return wowapiclient.NewWOWAPIClient(httpClient, "us", wowapiclient.WithBaseURL(s.BaseURL()))
+	}
+
+	c := newClient()
+	// the cached token is revoked before it expires
+	s.RevokeTokens(s.ClientID)
+	if _, err := c.GetAuctions(_zuljinID); err != nil {
+		t.Fatalf("GetAuctions() with a revoked token error = %v", err)
+	}
+	if got := s.TokenRequests(); got != 2 {
+		t.Errorf("TokenRequests() = %v, want 2", got)
+	}
+
+	// the new token replaced the revoked one in the cache
+	if _, err := newClient().GetAuctions(_zuljinID); err != nil {
+		t.Fatalf("GetAuctions() error = %v", err)
+	}
+	if got := s.TokenRequests(); got != 2 {
+		t.Errorf("TokenRequests() after the token was replaced = %v, want 2", got)
+	}
+}
+
+// recordingStore records the names of the blobs put into a Store.
+type recordingStore struct {
+	blobstore.Store
+	names []string
+}
+
+func (s *recordingStore) Put(ctx context.Context, name string, data []byte) error {
+	s.names = append(s.names, name)
+	return s.Store.Put(ctx, name, data)
+}
+
+// brokenStore fails every operation.
+type brokenStore struct{}
+
+func (brokenStore) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("bucket unavailable")
+}
+
+func (brokenStore) Put(context.Context, string, []byte) error {
+	return errors.New("bucket unavailable")
+}
+
+func TestGetHTTPClientTokenCacheUnavailable(t *testing.T) {
+	s := wowapitest.NewServer(testFixtures())
+	defer s.Close()
+
+	httpClient, err := wowapiclient.GetHTTPClient(context.Background(), s.Secrets(), "us",
+		wowapiclient.WithTokenURL(s.TokenURL()),
+		wowapiclient.WithTokenCache(brokenStore{}, "token.json"),
+	)
+	if err != nil {
+		t.Fatalf("GetHTTPClient() error = %v", err)
+	}
+	c := wowapiclient.NewWOWAPIClient(httpClient, "us", wowapiclient.WithBaseURL(s.BaseURL()))
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetAuctions(_zuljinID); err != nil {
+			t.Fatalf("GetAuctions() error = %v", err)
+		}
+	}
+	// the token is still kept in memory
+	if got := s.TokenRequests(); got != 1 {
+		t.Errorf("TokenRequests() = %v, want 1", got)
+	}
+}
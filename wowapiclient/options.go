@@ -0,0 +1,53 @@
+package wowapiclient
+
+import "net/url"
+
+// ClientOption configures a WOWAPIClient.
+type ClientOption func(*WOWAPIClient)
+
+// WithDefaultLocale sets the locale used for API calls which do not specify one, en_US by default.
+func WithDefaultLocale(l Locale) ClientOption {
+	return func(c *WOWAPIClient) {
+		c.locale = l
+	}
+}
+
+// WithBaseURL sends API calls to baseURL rather than the region's Blizzard API host, for example a
+// fake server in tests or a caching proxy. API paths are appended to the path of baseURL.
+func WithBaseURL(baseURL *url.URL) ClientOption {
+	return func(c *WOWAPIClient) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithUserAgent sets the User-Agent header of API calls, by default the HTTP client's own is used.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *WOWAPIClient) {
+		c.userAgent = userAgent
+	}
+}
+
+// CallOption changes how a single API call is made.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	namespace Namespace
+	// hrefNamespace is the full namespace, including region and any version, given in the query
+	// string of a followed link.
+	hrefNamespace string
+	locale        Locale
+	// quarantine collects invalid auctions rather than failing the call when set.
+	quarantine *Quarantine
+}
+
+// newCallOptions applies opts over the defaults for an endpoint in the given retail namespace.
+func (c *WOWAPIClient) newCallOptions(namespace Namespace, opts []CallOption) callOptions {
+	o := callOptions{
+		namespace: c.flavor.Namespace(namespace),
+		locale:    c.locale,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,211 @@
+package wowapiclient
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Profession is a crafting or gathering profession such as "Tailoring".
+type Profession struct {
+	ID   int
+	Name LocalizedString
+	// Type is "PRIMARY" or "SECONDARY", it is only set by GetProfession.
+	Type string
+	// SkillTiers is only set by GetProfession.
+	SkillTiers []SkillTier
+}
+
+// SkillTier is the part of a profession belonging to one expansion, such as "Kul Tiran Tailoring".
+type SkillTier struct {
+	ID                int
+	Name              LocalizedString
+	MinimumSkillLevel int
+	MaximumSkillLevel int
+	// Categories is only set by GetProfessionSkillTier.
+	Categories []RecipeCategory
+}
+
+// RecipeCategory is a group of recipes within a SkillTier.
+type RecipeCategory struct {
+	Name    LocalizedString
+	Recipes []RecipeRef
+}
+
+// RecipeRef refers to a recipe, use GetRecipe for its reagents.
+type RecipeRef struct {
+	ID   int
+	Name LocalizedString
+}
+
+// Recipe is a crafting recipe.
+type Recipe struct {
+	ID   int
+	Name LocalizedString
+	// CraftedItemID is 0 for recipes which craft a different item per faction, or which enchant an
+	// item rather than crafting one.
+	CraftedItemID         int
+	AllianceCraftedItemID int
+	HordeCraftedItemID    int
+	// CraftedQuantity is the average number of items crafted at once.
+	CraftedQuantity float64
+	Reagents        []Reagent
+}
+
+// Reagent is an item consumed by a recipe.
+type Reagent struct {
+	ItemID   int
+	Name     LocalizedString
+	Quantity int
+}
+
+// GetProfessions gets every profession, without their skill tiers.
+func (c *WOWAPIClient) GetProfessions(opts ...CallOption) ([]Profession, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := professionIndexResponse{}
+	if err := c.callAPI("/data/wow/profession/index", url.Values{}, &resp, o); err != nil {
+		return nil, err
+	}
+
+	professions := make([]Profession, 0, len(resp.Professions))
+	for _, p := range resp.Professions {
+		professions = append(professions, Profession{
+			ID:   p.ID,
+			Name: p.Name.inLocale(o.locale),
+		})
+	}
+	return professions, nil
+}
+
+// GetProfession gets a profession and its skill tiers, without their recipes.
+func (c *WOWAPIClient) GetProfession(id int, opts ...CallOption) (Profession, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := professionResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/profession/%v", id), url.Values{}, &resp, o); err != nil {
+		return Profession{}, err
+	}
+
+	p := Profession{
+		ID:         resp.ID,
+		Name:       resp.Name.inLocale(o.locale),
+		Type:       resp.Type.Type,
+		SkillTiers: make([]SkillTier, 0, len(resp.SkillTiers)),
+	}
+	for _, st := range resp.SkillTiers {
+		p.SkillTiers = append(p.SkillTiers, SkillTier{
+			ID:   st.ID,
+			Name: st.Name.inLocale(o.locale),
+		})
+	}
+	return p, nil
+}
+
+// GetProfessionSkillTier gets a skill tier of a profession and its recipes.
+func (c *WOWAPIClient) GetProfessionSkillTier(professionID, skillTierID int, opts ...CallOption) (SkillTier, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := skillTierResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/profession/%v/skill-tier/%v", professionID, skillTierID), url.Values{}, &resp, o); err != nil {
+		return SkillTier{}, err
+	}
+
+	st := SkillTier{
+		ID:                resp.ID,
+		Name:              resp.Name.inLocale(o.locale),
+		MinimumSkillLevel: resp.MinimumSkillLevel,
+		MaximumSkillLevel: resp.MaximumSkillLevel,
+		Categories:        make([]RecipeCategory, 0, len(resp.Categories)),
+	}
+	for _, cat := range resp.Categories {
+		rc := RecipeCategory{
+			Name:    cat.Name.inLocale(o.locale),
+			Recipes: make([]RecipeRef, 0, len(cat.Recipes)),
+		}
+		for _, r := range cat.Recipes {
+			rc.Recipes = append(rc.Recipes, RecipeRef{
+				ID:   r.ID,
+				Name: r.Name.inLocale(o.locale),
+			})
+		}
+		st.Categories = append(st.Categories, rc)
+	}
+	return st, nil
+}
+
+// GetRecipe gets a recipe with its reagents and crafted item.
+func (c *WOWAPIClient) GetRecipe(id int, opts ...CallOption) (Recipe, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp := recipeResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/recipe/%v", id), url.Values{}, &resp, o); err != nil {
+		return Recipe{}, err
+	}
+
+	r := Recipe{
+		ID:                    resp.ID,
+		Name:                  resp.Name.inLocale(o.locale),
+		CraftedItemID:         resp.CraftedItem.ID,
+		AllianceCraftedItemID: resp.AllianceCraftedItem.ID,
+		HordeCraftedItemID:    resp.HordeCraftedItem.ID,
+		CraftedQuantity:       resp.CraftedQuantity.Value,
+		Reagents:              make([]Reagent, 0, len(resp.Reagents)),
+	}
+	if r.CraftedQuantity == 0 && resp.CraftedQuantity.Maximum > 0 {
+		r.CraftedQuantity = (resp.CraftedQuantity.Minimum + resp.CraftedQuantity.Maximum) / 2
+	}
+	for _, rg := range resp.Reagents {
+		r.Reagents = append(r.Reagents, Reagent{
+			ItemID:   rg.Reagent.ID,
+			Name:     rg.Reagent.Name.inLocale(o.locale),
+			Quantity: rg.Quantity,
+		})
+	}
+	return r, nil
+}
+
+// namedLink is a reference to another API resource with its name.
+type namedLink struct {
+	Key  Link            `json:"key"`
+	Name LocalizedString `json:"name"`
+	ID   int             `json:"id"`
+}
+
+type professionIndexResponse struct {
+	responseLinks
+	Professions []namedLink `json:"professions"`
+}
+
+type professionResponse struct {
+	responseLinks
+	ID         int             `json:"id"`
+	Name       LocalizedString `json:"name"`
+	Type       typedName       `json:"type"`
+	SkillTiers []namedLink     `json:"skill_tiers"`
+}
+
+type skillTierResponse struct {
+	responseLinks
+	ID                int             `json:"id"`
+	Name              LocalizedString `json:"name"`
+	MinimumSkillLevel int             `json:"minimum_skill_level"`
+	MaximumSkillLevel int             `json:"maximum_skill_level"`
+	Categories        []struct {
+		Name    LocalizedString `json:"name"`
+		Recipes []namedLink     `json:"recipes"`
+	} `json:"categories"`
+}
+
+type recipeResponse struct {
+	responseLinks
+	ID                  int             `json:"id"`
+	Name                LocalizedString `json:"name"`
+	CraftedItem         namedLink       `json:"crafted_item"`
+	AllianceCraftedItem namedLink       `json:"alliance_crafted_item"`
+	HordeCraftedItem    namedLink       `json:"horde_crafted_item"`
+	Reagents            []struct {
+		Reagent  namedLink `json:"reagent"`
+		Quantity int       `json:"quantity"`
+	} `json:"reagents"`
+	CraftedQuantity struct {
+		Value   float64 `json:"value"`
+		Minimum float64 `json:"minimum"`
+		Maximum float64 `json:"maximum"`
+	} `json:"crafted_quantity"`
+}
@@ -0,0 +1,39 @@
+package wowapiclient
+
+// Quarantine is the auctions rejected by validation during calls made WithQuarantine.
+type Quarantine []QuarantinedAuction
+
+// QuarantinedAuction is an auction which failed validation and why.
+type QuarantinedAuction struct {
+	Auction Auction
+	Reason  string
+	// Kept is set for auctions which were kept with TimeLeftUnknown rather than left out, Reason then
+	// holds the time left the client did not recognise.
+	Kept bool
+}
+
+// WithQuarantine makes auction calls tolerant of bad data. Rather than failing the whole call, auctions
+// which fail validation, such as those with no quantity or no price, are appended to q and left out of
+// the result, and auctions with a time left the client does not recognise are kept with
+// TimeLeftUnknown and also appended to q, so that new values are noticed. q is not safe to share
+// between concurrent calls.
+func WithQuarantine(q *Quarantine) CallOption {
+	return func(o *callOptions) {
+		o.quarantine = q
+	}
+}
+
+func (q *Quarantine) add(a Auction, reason error, kept bool) {
+	*q = append(*q, QuarantinedAuction{Auction: a, Reason: reason.Error(), Kept: kept})
+}
+
+// Rejected returns how many auctions were left out of the result.
+func (q Quarantine) Rejected() int {
+	n := 0
+	for _, a := range q {
+		if !a.Kept {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,182 @@
+package wowapiclient
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const _maxSuggestions = 3
+
+// ConnectedRealms is an index of connected realms. A connected realm can be found by the name, slug or
+// ID of any of its realms, names are matched regardless of case, spaces and punctuation so "Zul'jin",
+// "zuljin" and "ZULJIN" are all the same realm.
+type ConnectedRealms struct {
+	groups    []ConnectedRealm
+	byID      map[int]int
+	byRealmID map[int]int
+	byName    map[string]int
+}
+
+// NewConnectedRealms indexes the given connected realms.
+func NewConnectedRealms(crs []ConnectedRealm) ConnectedRealms {
+	groups := append([]ConnectedRealm(nil), crs...)
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+
+	idx := ConnectedRealms{
+		groups:    groups,
+		byID:      make(map[int]int, len(groups)),
+		byRealmID: make(map[int]int),
+		byName:    make(map[string]int),
+	}
+	for i, cr := range groups {
+		idx.byID[cr.ID] = i
+		for _, r := range cr.Realms {
+			idx.byRealmID[r.ID] = i
+			idx.byName[NormalizeRealmName(r.Slug)] = i
+			for _, n := range r.Name {
+				idx.byName[NormalizeRealmName(n)] = i
+			}
+		}
+	}
+	return idx
+}
+
+// NormalizeRealmName lowercases a realm name or slug and drops everything but letters and digits, so
+// names and slugs of the same realm normalize to the same string.
+func NormalizeRealmName(n string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(n) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// All returns every connected realm ordered by ID.
+func (cr ConnectedRealms) All() []ConnectedRealm {
+	return cr.groups
+}
+
+// Len returns the number of connected realms.
+func (cr ConnectedRealms) Len() int {
+	return len(cr.groups)
+}
+
+// Get gets the connected realm containing the realm with the given name, slug or realm ID. The error
+// suggests similarly named realms if there is no match.
+func (cr ConnectedRealms) Get(n string) (ConnectedRealm, error) {
+	if i, ok := cr.byName[NormalizeRealmName(n)]; ok {
+		return cr.groups[i], nil
+	}
+	if id, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+		if i, ok := cr.byRealmID[id]; ok {
+			return cr.groups[i], nil
+		}
+	}
+
+	if suggestions := cr.Suggest(n); len(suggestions) > 0 {
+		return ConnectedRealm{}, fmt.Errorf("unknown connected realm %v, did you mean %v?", n, strings.Join(suggestions, ", "))
+	}
+	return ConnectedRealm{}, fmt.Errorf("unknown connected realm %v", n)
+}
+
+// GetBySlug gets the connected realm which contains the realm with the given slug, such as "zuljin".
+func (cr ConnectedRealms) GetBySlug(slug string) (ConnectedRealm, error) {
+	return cr.Get(slug)
+}
+
+// GetByRealmID gets the connected realm which contains the realm with the given realm ID.
+func (cr ConnectedRealms) GetByRealmID(id int) (ConnectedRealm, error) {
+	if i, ok := cr.byRealmID[id]; ok {
+		return cr.groups[i], nil
+	}
+	return ConnectedRealm{}, fmt.Errorf("unknown realm id %v", id)
+}
+
+// GetByID gets a connected realm by its connected realm ID.
+func (cr ConnectedRealms) GetByID(id int) (ConnectedRealm, error) {
+	if i, ok := cr.byID[id]; ok {
+		return cr.groups[i], nil
+	}
+	return ConnectedRealm{}, fmt.Errorf("unknown connected realm id %v", id)
+}
+
+// Suggest returns the names of up to three realms whose names are close to n, closest first.
+func (cr ConnectedRealms) Suggest(n string) []string {
+	normalized := NormalizeRealmName(n)
+	if normalized == "" {
+		return nil
+	}
+	// allow roughly one typo for every three characters
+	maxDistance := len(normalized)/3 + 1
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	for _, c := range cr.groups {
+		for _, r := range c.Realms {
+			d := levenshtein(normalized, NormalizeRealmName(r.Slug))
+			if d <= maxDistance {
+				candidates = append(candidates, candidate{name: r.Name.String(), distance: d})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var suggestions []string
+	for _, c := range candidates {
+		if len(suggestions) == _maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, c.name)
+	}
+	return suggestions
+}
+
+// levenshtein is the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,46 @@
+package wowapiclient_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+func TestConnectedRealmsGet(t *testing.T) {
+	crs := wowapiclient.NewConnectedRealms(testFixtures().ConnectedRealms)
+
+	for _, n := range []string{"Zul'jin", "zuljin", "ZULJIN", " Zul jin ", "61"} {
+		cr, err := crs.Get(n)
+		if err != nil || cr.ID != _zuljinID {
+			t.Errorf("Get(%q) = %v, %v, want connected realm %v", n, cr.ID, err, _zuljinID)
+		}
+	}
+
+	_, err := crs.Get("zuljn")
+	if err == nil || !strings.Contains(err.Error(), "did you mean Zul'jin?") {
+		t.Errorf("Get(zuljn) error = %v, want a suggestion", err)
+	}
+
+	if _, err := crs.Get("Area 52"); err == nil {
+		t.Error("Get(Area 52) error = nil")
+	}
+
+	if cr, err := crs.GetByRealmID(60); err != nil || cr.ID != _stormrage {
+		t.Errorf("GetByRealmID(60) = %v, %v, want %v", cr.ID, err, _stormrage)
+	}
+}
+
+func TestNormalizeRealmName(t *testing.T) {
+	tests := map[string]string{
+		"Zul'jin":        "zuljin",
+		"Area 52":        "area52",
+		"Aman'Thul":      "amanthul",
+		"Blackwing Lair": "blackwinglair",
+	}
+	for n, want := range tests {
+		if got := wowapiclient.NormalizeRealmName(n); got != want {
+			t.Errorf("NormalizeRealmName(%q) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package wowapiclient
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// GetRealms gets every realm in the clients region, including the connected realm each belongs to.
+func (c *WOWAPIClient) GetRealms(opts ...CallOption) ([]Realm, error) {
+	o := c.newCallOptions(NamespaceDynamic, opts)
+	resp := realmIndexResponse{}
+	if err := c.callAPI("/data/wow/realm/index", url.Values{}, &resp, o); err != nil {
+		return nil, err
+	}
+
+	realms := make([]Realm, 0, len(resp.Realms))
+	for _, r := range resp.Realms {
+		realm, err := c.getRealm(r.Slug, o)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch realm %v", r.Slug)
+		}
+		realms = append(realms, realm)
+	}
+	return realms, nil
+}
+
+func (c *WOWAPIClient) getRealm(slug string, o callOptions) (Realm, error) {
+	resp := realmResponse{}
+	if err := c.callAPI(fmt.Sprintf("/data/wow/realm/%v", slug), url.Values{}, &resp, o); err != nil {
+		return Realm{}, err
+	}
+
+	realm := resp.toRealm(o.locale)
+	if resp.ConnectedRealm.Href != "" {
+		id, err := resp.ConnectedRealm.ID()
+		if err != nil {
+			return Realm{}, err
+		}
+		realm.ConnectedRealmID = id
+	}
+	return realm, nil
+}
+
+type realmIndexResponse struct {
+	responseLinks
+	Realms []struct {
+		Key  Link            `json:"key"`
+		Name LocalizedString `json:"name"`
+		ID   int             `json:"id"`
+		Slug string          `json:"slug"`
+	} `json:"realms"`
+}
+
+// RealmMap maps realm IDs to the connected realm they belong to and back.
+type RealmMap struct {
+	// ConnectedRealmIDs maps a realm ID to its connected realm ID.
+	ConnectedRealmIDs map[int]int `json:"connectedRealmIDs"`
+	// RealmIDs maps a connected realm ID to its realm IDs, in order.
+	RealmIDs map[int][]int `json:"realmIDs"`
+}
+
+// NewRealmMap maps the given realms. Realms without a connected realm are left out.
+func NewRealmMap(realms []Realm) RealmMap {
+	m := RealmMap{
+		ConnectedRealmIDs: make(map[int]int, len(realms)),
+		RealmIDs:          make(map[int][]int),
+	}
+	for _, r := range realms {
+		if r.ConnectedRealmID == 0 {
+			continue
+		}
+		m.ConnectedRealmIDs[r.ID] = r.ConnectedRealmID
+		m.RealmIDs[r.ConnectedRealmID] = append(m.RealmIDs[r.ConnectedRealmID], r.ID)
+	}
+	for _, ids := range m.RealmIDs {
+		sort.Ints(ids)
+	}
+	return m
+}
+
+// RealmMerge records a realm moving to a different connected realm.
+type RealmMerge struct {
+	RealmID              int
+	FromConnectedRealmID int
+	ToConnectedRealmID   int
+	// FromConnectedRealmGone is true if no realms are left in FromConnectedRealmID.
+	FromConnectedRealmGone bool
+}
+
+// MergesSince returns every realm which belongs to a different connected realm than it did in prev,
+// ordered by realm ID. Realms which are new since prev are not merges.
+func (m RealmMap) MergesSince(prev RealmMap) []RealmMerge {
+	var merges []RealmMerge
+	for realmID, to := range m.ConnectedRealmIDs {
+		from, ok := prev.ConnectedRealmIDs[realmID]
+		if !ok || from == to {
+			continue
+		}
+
+		_, stillExists := m.RealmIDs[from]
+		merges = append(merges, RealmMerge{
+			RealmID:                realmID,
+			FromConnectedRealmID:   from,
+			ToConnectedRealmID:     to,
+			FromConnectedRealmGone: !stillExists,
+		})
+	}
+
+	sort.Slice(merges, func(i, j int) bool {
+		return merges[i].RealmID < merges[j].RealmID
+	})
+	return merges
+}
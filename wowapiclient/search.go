@@ -0,0 +1,204 @@
+package wowapiclient
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SearchQuery is a query against one of the API search endpoints.
+type SearchQuery struct {
+	// Filters match result fields by their API names, for example "name.en_US": "Thunderfury".
+	Filters map[string]string
+	// OrderBy sorts results by API field names, for example "id" or "name.en_US:desc".
+	OrderBy []string
+	// Page is the 1 based page to fetch, the first page by default.
+	Page int
+	// PageSize is the number of results per page, the API default of 100 by default.
+	PageSize int
+}
+
+// ItemNameQuery searches for items whose name in the given locale contains name.
+func ItemNameQuery(name string, l Locale) SearchQuery {
+	return SearchQuery{
+		Filters: map[string]string{"name." + string(l): name},
+		OrderBy: []string{"id"},
+	}
+}
+
+func (q SearchQuery) values() url.Values {
+	v := url.Values{}
+	for field, value := range q.Filters {
+		v.Set(field, value)
+	}
+	if len(q.OrderBy) > 0 {
+		v.Set("orderby", strings.Join(q.OrderBy, ","))
+	}
+	if q.Page > 0 {
+		v.Set("_page", strconv.Itoa(q.Page))
+	}
+	if q.PageSize > 0 {
+		v.Set("_pageSize", strconv.Itoa(q.PageSize))
+	}
+	return v
+}
+
+// ItemSearchPage is a single page of item search results.
+type ItemSearchPage struct {
+	Page      int
+	PageSize  int
+	PageCount int
+	Items     []Item
+}
+
+// SearchItems gets a single page of items matching q.
+func (c *WOWAPIClient) SearchItems(q SearchQuery, opts ...CallOption) (ItemSearchPage, error) {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	resp, err := c.search(_itemSearchPath, q, o)
+	if err != nil {
+		return ItemSearchPage{}, err
+	}
+
+	page := ItemSearchPage{
+		Page:      resp.Page,
+		PageSize:  resp.PageSize,
+		PageCount: resp.PageCount,
+		Items:     make([]Item, 0, len(resp.Results)),
+	}
+	for _, r := range resp.Results {
+		item, err := decodeItemResult(r.Data, o.locale)
+		if err != nil {
+			return ItemSearchPage{}, err
+		}
+		page.Items = append(page.Items, item)
+	}
+	return page, nil
+}
+
+// SearchAllItems returns an iterator over every item matching q, starting from q.Page. Pages are
+// fetched as the iterator reaches them.
+func (c *WOWAPIClient) SearchAllItems(q SearchQuery, opts ...CallOption) *ItemIterator {
+	o := c.newCallOptions(NamespaceStatic, opts)
+	return &ItemIterator{
+		it:     newSearchIterator(c, _itemSearchPath, q, o),
+		locale: o.locale,
+	}
+}
+
+const _itemSearchPath = "/data/wow/search/item"
+
+// ItemIterator walks every page of an item search.
+type ItemIterator struct {
+	it     *searchIterator
+	locale Locale
+	item   Item
+	err    error
+}
+
+// Next advances to the next item, it returns false when there are no more items or an error occurred.
+func (it *ItemIterator) Next() bool {
+	if it.err != nil || !it.it.next() {
+		return false
+	}
+
+	it.item, it.err = decodeItemResult(it.it.current(), it.locale)
+	return it.err == nil
+}
+
+// Item returns the current item.
+func (it *ItemIterator) Item() Item {
+	return it.item
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *ItemIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.it.err
+}
+
+func decodeItemResult(data json.RawMessage, l Locale) (Item, error) {
+	resp := itemResponse{}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return Item{}, errors.Wrap(err, "failed to decode item search result")
+	}
+	return resp.toItem(l), nil
+}
+
+// search fetches a single page of search results, leaving each result for the caller to decode.
+func (c *WOWAPIClient) search(path string, q SearchQuery, o callOptions) (searchResponse, error) {
+	resp := searchResponse{}
+	if err := c.callAPI(path, q.values(), &resp, o); err != nil {
+		return searchResponse{}, err
+	}
+	return resp, nil
+}
+
+// searchIterator walks the pages of any search endpoint.
+type searchIterator struct {
+	c    *WOWAPIClient
+	path string
+	q    SearchQuery
+	o    callOptions
+
+	fetched   bool
+	pageCount int
+	results   []searchResult
+	cur       json.RawMessage
+	err       error
+}
+
+func newSearchIterator(c *WOWAPIClient, path string, q SearchQuery, o callOptions) *searchIterator {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	return &searchIterator{
+		c:    c,
+		path: path,
+		q:    q,
+		o:    o,
+	}
+}
+
+func (it *searchIterator) next() bool {
+	for len(it.results) == 0 {
+		if it.err != nil || (it.fetched && it.q.Page > it.pageCount) {
+			return false
+		}
+
+		resp, err := it.c.search(it.path, it.q, it.o)
+		if err != nil {
+			it.err = errors.Wrapf(err, "failed to fetch search page %v", it.q.Page)
+			return false
+		}
+		it.fetched = true
+		it.pageCount = resp.PageCount
+		it.results = resp.Results
+		it.q.Page++
+	}
+
+	it.cur = it.results[0].Data
+	it.results = it.results[1:]
+	return true
+}
+
+func (it *searchIterator) current() json.RawMessage {
+	return it.cur
+}
+
+type searchResponse struct {
+	Page        int            `json:"page"`
+	PageSize    int            `json:"pageSize"`
+	MaxPageSize int            `json:"maxPageSize"`
+	PageCount   int            `json:"pageCount"`
+	Results     []searchResult `json:"results"`
+}
+
+type searchResult struct {
+	Key  Link            `json:"key"`
+	Data json.RawMessage `json:"data"`
+}
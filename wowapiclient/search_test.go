@@ -0,0 +1,173 @@
+package wowapiclient_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+	"github.com/ZymoticB/wowauctiondata/wowapiclient/wowapitest"
+	"github.com/pkg/errors"
+)
+
+const _itemSearchPath = "/data/wow/search/item"
+
+func searchFixtures() wowapitest.Fixtures {
+	f := testFixtures()
+	for _, i := range []struct {
+		id   int
+		name string
+	}{
+		{id: 2592, name: "Wool Cloth"},
+		{id: 4306, name: "Silk Cloth"},
+		{id: 4338, name: "Mageweave Cloth"},
+		{id: 765, name: "Silverleaf"},
+	} {
+		f.Items = append(f.Items, wowapiclient.ItemDetail{Item: wowapiclient.Item{
+			ID:   i.id,
+			Name: wowapiclient.LocalizedString{wowapiclient.LocaleEnUS: i.name},
+		}})
+	}
+	return f
+}
+
+func TestSearchItems(t *testing.T) {
+	s := wowapitest.NewServer(searchFixtures())
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	q := wowapiclient.ItemNameQuery("cloth", wowapiclient.LocaleEnUS)
+	q.PageSize = 3
+	page, err := c.SearchItems(q)
+	if err != nil {
+		t.Fatalf("SearchItems() error = %v", err)
+	}
+	if page.Page != 1 || page.PageSize != 3 || page.PageCount != 2 {
+		t.Errorf("SearchItems() page %v of %v with size %v, want 1 of 2 with size 3", page.Page, page.PageCount, page.PageSize)
+	}
+	if got := itemIDs(page.Items); !reflect.DeepEqual(got, []int{_linenCloth, 2592, 4306}) {
+		t.Errorf("SearchItems() items = %v", got)
+	}
+	if got := page.Items[0].Name.String(); got != "Linen Cloth" {
+		t.Errorf("item name = %q, want Linen Cloth", got)
+	}
+
+	q.Page = 2
+	page, err = c.SearchItems(q)
+	if err != nil {
+		t.Fatalf("SearchItems() of the last page error = %v", err)
+	}
+	if got := itemIDs(page.Items); !reflect.DeepEqual(got, []int{4338}) {
+		t.Errorf("SearchItems() last page items = %v, want [4338]", got)
+	}
+}
+
+func TestSearchAllItems(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		page         int
+		pageSize     int
+		want         []int
+		wantRequests int
+	}{
+		{
+			name:         "every page",
+			query:        "cloth",
+			pageSize:     2,
+			want:         []int{_linenCloth, 2592, 4306, 4338},
+			wantRequests: 2,
+		},
+		{
+			name:         "last page partly filled",
+			query:        "cloth",
+			pageSize:     3,
+			want:         []int{_linenCloth, 2592, 4306, 4338},
+			wantRequests: 2,
+		},
+		{
+			name:         "starting from the last page",
+			query:        "cloth",
+			page:         2,
+			pageSize:     3,
+			want:         []int{4338},
+			wantRequests: 1,
+		},
+		{
+			name:         "single page",
+			query:        "silverleaf",
+			want:         []int{765},
+			wantRequests: 1,
+		},
+		{
+			name:         "no results",
+			query:        "thunderfury",
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := wowapitest.NewServer(searchFixtures())
+			defer s.Close()
+
+			q := wowapiclient.ItemNameQuery(tt.query, wowapiclient.LocaleEnUS)
+			q.Page = tt.page
+			q.PageSize = tt.pageSize
+			it := newTestClient(t, s).SearchAllItems(q)
+
+			var got []int
+			for it.Next() {
+				got = append(got, it.Item().ID)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("Err() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchAllItems() = %v, want %v", got, tt.want)
+			}
+			if got := s.Requests(_itemSearchPath); got != tt.wantRequests {
+				t.Errorf("Requests() = %v, want %v", got, tt.wantRequests)
+			}
+
+			// an exhausted iterator makes no more requests
+			if it.Next() {
+				t.Error("Next() after the last item = true")
+			}
+			if got := s.Requests(_itemSearchPath); got != tt.wantRequests {
+				t.Errorf("Requests() after the last item = %v, want %v", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestSearchAllItemsError(t *testing.T) {
+	s := wowapitest.NewServer(searchFixtures())
+	defer s.Close()
+
+	q := wowapiclient.ItemNameQuery("cloth", wowapiclient.LocaleEnUS)
+	q.PageSize = 2
+	it := newTestClient(t, s).SearchAllItems(q)
+	for i := 0; i < 2; i++ {
+		if !it.Next() {
+			t.Fatalf("Next() of item %v = false, err %v", i, it.Err())
+		}
+	}
+
+	// the second page fails
+	s.AddFault(_itemSearchPath, wowapitest.Fault{StatusCode: http.StatusServiceUnavailable})
+	if it.Next() {
+		t.Error("Next() after a failed page = true")
+	}
+	if se, ok := errors.Cause(it.Err()).(*wowapiclient.StatusError); !ok || se.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Err() = %v, want status %v", it.Err(), http.StatusServiceUnavailable)
+	}
+}
+
+func itemIDs(items []wowapiclient.Item) []int {
+	ids := make([]int, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.ID)
+	}
+	return ids
+}
@@ -0,0 +1,35 @@
+package wowapiclient
+
+import (
+	"net/url"
+	"time"
+)
+
+// TokenPrice is the price of a WoW Token in a region.
+type TokenPrice struct {
+	Region string
+	// Price is in copper.
+	Price       int
+	LastUpdated time.Time
+}
+
+// GetTokenPrice gets the current price of a WoW Token in the clients region.
+func (c *WOWAPIClient) GetTokenPrice(opts ...CallOption) (TokenPrice, error) {
+	resp := tokenResponse{}
+	if err := c.callAPI("/data/wow/token/index", url.Values{}, &resp, c.newCallOptions(NamespaceDynamic, opts)); err != nil {
+		return TokenPrice{}, err
+	}
+
+	return TokenPrice{
+		Region:      c.region,
+		Price:       resp.Price,
+		LastUpdated: time.Unix(0, resp.LastUpdatedTimestamp*int64(time.Millisecond)).UTC(),
+	}, nil
+}
+
+type tokenResponse struct {
+	responseLinks
+	// LastUpdatedTimestamp is in milliseconds since the epoch.
+	LastUpdatedTimestamp int64 `json:"last_updated_timestamp"`
+	Price                int   `json:"price"`
+}
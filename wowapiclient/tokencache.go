@@ -0,0 +1,144 @@
+package wowapiclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/blobstore"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// _tokenRefreshBefore is how long before a token expires it is replaced, so that requests are never
+// made with a token which expires in flight.
+const _tokenRefreshBefore = 10 * time.Minute
+
+// WithTokenCache caches oauth2 tokens in store under name, suffixed with the region and a hash of the
+// client ID, so that processes sharing store, such as successive cloud function instances sharing a
+// bucket, reuse a token until it is about to expire rather than each fetching their own. A token the API
+// rejects is dropped from store. The cache is best effort, a token which cannot be loaded is fetched and
+// one which cannot be saved is still used.
+//
+// The cached token is a bearer credential for the API, so store should only be readable by the
+// processes which share it.
+func WithTokenCache(store blobstore.Store, name string) HTTPClientOption {
+	return func(o *httpClientOptions) {
+		o.tokenStore = store
+		o.tokenName = name
+	}
+}
+
+// cachingTokenSource fetches a new token from fetch only when neither it nor its store have one which
+// is valid for at least _tokenRefreshBefore.
+type cachingTokenSource struct {
+	ctx   context.Context
+	fetch func(context.Context) (*oauth2.Token, error)
+	// store is nil when tokens are only cached in memory.
+	store blobstore.Store
+	name  string
+	now   func() time.Time
+
+	mu    sync.Mutex
+	token *oauth2.Token
+	// rejected is set when the API rejected token, so the token in the store is not reused either.
+	rejected bool
+}
+
+// Token implements oauth2.TokenSource.
+func (s *cachingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fresh(s.token) {
+		return s.token, nil
+	}
+
+	if s.store != nil && !s.rejected {
+		t, err := s.load()
+		if err != nil {
+			log.Printf("fetching a new oauth2 token: %v", err)
+		}
+		if s.fresh(t) {
+			s.token = t
+			return t, nil
+		}
+	}
+
+	t, err := s.fetch(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = t
+	s.rejected = false
+
+	if s.store != nil {
+		if err := s.save(t); err != nil {
+			log.Printf("using an uncached oauth2 token: %v", err)
+		}
+	}
+	return t, nil
+}
+
+// invalidate drops the current token after the API rejected it, so the next call to Token fetches a
+// new one. The token is dropped from the store too, so that other processes do not load it.
+func (s *cachingTokenSource) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+	s.rejected = true
+
+	if s.store != nil {
+		// an empty token is replaced like an expired one
+		if err := s.save(&oauth2.Token{}); err != nil {
+			log.Printf("failed to drop the cached oauth2 token: %v", err)
+		}
+	}
+}
+
+// fresh returns true if t will not expire within _tokenRefreshBefore. Tokens without an expiry never
+// expire.
+func (s *cachingTokenSource) fresh(t *oauth2.Token) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || t.Expiry.Sub(s.now()) > _tokenRefreshBefore
+}
+
+// tokenCacheName is the name the token of clientID in region is cached under.
+func tokenCacheName(name, region, clientID string) string {
+	sum := sha256.Sum256([]byte(clientID))
+	return fmt.Sprintf("%s.%s.%x", name, region, sum[:6])
+}
+
+func (s *cachingTokenSource) load() (*oauth2.Token, error) {
+	b, err := s.store.Get(s.ctx, s.name)
+	if err == blobstore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load cached oauth2 token")
+	}
+
+	t := &oauth2.Token{}
+	if err := json.Unmarshal(b, t); err != nil {
+		// a corrupt token is replaced like an expired one
+		return nil, nil
+	}
+	return t, nil
+}
+
+func (s *cachingTokenSource) save(t *oauth2.Token) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode oauth2 token")
+	}
+	if err := s.store.Put(s.ctx, s.name, b); err != nil {
+		return errors.Wrap(err, "failed to cache oauth2 token")
+	}
+	return nil
+}
@@ -0,0 +1,625 @@
+// Package wowapitest provides a fake Battle.net API server for tests and local development. It serves
+// fixture data in the same shape as the real API, checks oauth2 tokens and namespaces the way the real
+// API does, and can inject faults such as latency, 429s, 5xx and malformed JSON.
+package wowapitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZymoticB/wowauctiondata/wowapiclient"
+)
+
+const (
+	_defaultRegion = "us"
+	_tokenPath     = "/oauth/token"
+	_tokenLifetime = 24 * time.Hour
+
+	_defaultSearchPageSize = 100
+	_maxSearchPageSize     = 1000
+)
+
+// Fixtures is the data served by a Server.
+type Fixtures struct {
+	// Region is the region whose namespaces are served, "us" by default.
+	Region          string
+	ConnectedRealms []wowapiclient.ConnectedRealm
+	// Auctions are the auctions of each connected realm keyed by connected realm ID.
+	Auctions    map[int][]wowapiclient.Auction
+	Commodities []wowapiclient.Auction
+	Items       []wowapiclient.ItemDetail
+}
+
+// Fault changes how a Server responds to requests.
+type Fault struct {
+	// Latency delays the response.
+	Latency time.Duration
+	// StatusCode responds with an error status rather than the fixture when non-zero. 429 responses
+	// include a Retry-After header.
+	StatusCode int
+	// MalformedJSON truncates the response body.
+	MalformedJSON bool
+	// Count is how many requests the fault applies to, or 0 for every request.
+	Count int
+	// ClientID limits the fault to API requests authenticated by the client, when set.
+	ClientID string
+}
+
+// Server is a fake Battle.net API server. Requests in any flavor of the fixture region's namespaces
+// are served the same fixtures.
+type Server struct {
+	*httptest.Server
+
+	// ClientID and ClientSecret are the default credentials the server issues tokens for, see AddClient
+	// for more.
+	ClientID     string
+	ClientSecret string
+
+	fixtures        Fixtures
+	connectedRealms map[int]wowapiclient.ConnectedRealm
+	items           map[int]wowapiclient.ItemDetail
+
+	mu             sync.Mutex
+	clients        map[string]string
+	faults         map[string][]*Fault
+	tokens         map[string]string
+	requests       map[string]int
+	clientRequests map[string]int
+	tokenRequests  int
+}
+
+// NewServer starts a Server serving f. Callers must Close it.
+func NewServer(f Fixtures) *Server {
+	if f.Region == "" {
+		f.Region = _defaultRegion
+	}
+	s := &Server{
+		ClientID:        "wowapitest-client-id",
+		ClientSecret:    "wowapitest-client-secret",
+		fixtures:        f,
+		connectedRealms: make(map[int]wowapiclient.ConnectedRealm, len(f.ConnectedRealms)),
+		items:           make(map[int]wowapiclient.ItemDetail, len(f.Items)),
+		clients:         make(map[string]string),
+		faults:          make(map[string][]*Fault),
+		tokens:          make(map[string]string),
+		requests:        make(map[string]int),
+		clientRequests:  make(map[string]int),
+	}
+	s.clients[s.ClientID] = s.ClientSecret
+	for _, cr := range f.ConnectedRealms {
+		s.connectedRealms[cr.ID] = cr
+	}
+	for _, i := range f.Items {
+		s.items[i.ID] = i
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddClient makes the server issue tokens to another client, for example to test a
+// wowapiclient.CredentialPool.
+func (s *Server) AddClient(clientID, clientSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[clientID] = clientSecret
+}
+
+// RevokeClient stops the server issuing tokens to a client and rejects the tokens it already issued to
+// it.
+func (s *Server) RevokeClient(clientID string) {
+	s.mu.Lock()
+	delete(s.clients, clientID)
+	s.mu.Unlock()
+	s.RevokeTokens(clientID)
+}
+
+// RevokeTokens rejects the tokens already issued to a client before they expire. New tokens are still
+// issued to it.
+func (s *Server) RevokeTokens(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, id := range s.tokens {
+		if id == clientID {
+			delete(s.tokens, token)
+		}
+	}
+}
+
+// Secrets are the default credentials accepted by the server.
+func (s *Server) Secrets() wowapiclient.OAuth2Secrets {
+	return wowapiclient.OAuth2Secrets{
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+	}
+}
+
+// TokenURL is the oauth2 token URL of the server, see wowapiclient.WithTokenURL.
+func (s *Server) TokenURL() string {
+	return s.URL + _tokenPath
+}
+
+// BaseURL is the API base URL of the server, see wowapiclient.WithBaseURL.
+func (s *Server) BaseURL() *url.URL {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// Transport sends every request to the server regardless of its host, for code which creates its own
+// WOWAPIClient with the default API host.
+func (s *Server) Transport() http.RoundTripper {
+	return redirectTransport{target: s.BaseURL()}
+}
+
+// HTTPClient gets an authenticated HTTP Client from the server, which sends every request to the server.
+func (s *Server) HTTPClient(ctx context.Context) (*http.Client, error) {
+	return wowapiclient.GetHTTPClient(ctx, s.Secrets(), s.fixtures.Region,
+		wowapiclient.WithTokenURL(s.TokenURL()),
+		wowapiclient.WithTransport(s.Transport()),
+	)
+}
+
+// NewClient creates a WOWAPIClient for the server's region which calls the server.
+func (s *Server) NewClient(ctx context.Context, opts ...wowapiclient.ClientOption) (*wowapiclient.WOWAPIClient, error) {
+	httpClient, err := s.HTTPClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]wowapiclient.ClientOption{wowapiclient.WithBaseURL(s.BaseURL())}, opts...)
+	return wowapiclient.NewWOWAPIClient(httpClient, s.fixtures.Region, opts...), nil
+}
+
+// AddFault injects f into responses to requests for path, such as "/data/wow/item/25". Faults for an
+// empty path apply to every API request but not to token requests. Faults apply in the order they are
+// added, a fault with a Count stops applying once it has been used Count times.
+func (s *Server) AddFault(path string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = append(s.faults[path], &f)
+}
+
+// Requests is how many requests have been made for path, including failed ones.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// ClientRequests is how many API requests have been authenticated by the client, including failed ones.
+func (s *Server) ClientRequests(clientID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientRequests[clientID]
+}
+
+// TokenRequests is how many tokens have been requested.
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.takeFault(r.URL.Path, s.clientOf(r))
+	if !ok {
+		s.route(w, r)
+		return
+	}
+
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if f.StatusCode != 0 {
+		if f.StatusCode == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		writeError(w, f.StatusCode)
+		return
+	}
+
+	if f.MalformedJSON {
+		rec := httptest.NewRecorder()
+		s.route(rec, r)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		body := rec.Body.Bytes()
+		w.Write(body[:len(body)/2])
+		return
+	}
+
+	s.route(w, r)
+}
+
+// takeFault counts the request and returns the fault to apply to it, if any.
+func (s *Server) takeFault(path, clientID string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path == _tokenPath {
+		s.tokenRequests++
+	} else {
+		s.requests[path]++
+		if clientID != "" {
+			s.clientRequests[clientID]++
+		}
+	}
+
+	keys := []string{path}
+	if path != _tokenPath {
+		keys = append(keys, "")
+	}
+	for _, key := range keys {
+		for i, f := range s.faults[key] {
+			if f.ClientID != "" && f.ClientID != clientID {
+				continue
+			}
+			if f.Count > 0 {
+				f.Count--
+				if f.Count == 0 {
+					s.faults[key] = append(s.faults[key][:i:i], s.faults[key][i+1:]...)
+				}
+			}
+			return *f, true
+		}
+	}
+	return Fault{}, false
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == _tokenPath {
+		s.serveToken(w, r)
+		return
+	}
+
+	if s.clientOf(r) == "" {
+		writeError(w, http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/data/wow/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "connected-realm" && parts[1] == "index":
+		s.serveDynamic(w, r, s.connectedRealmIndex)
+	case len(parts) == 2 && parts[0] == "connected-realm":
+		s.serveDynamic(w, r, func(r *http.Request) (interface{}, bool) {
+			return s.connectedRealm(r, parts[1])
+		})
+	case len(parts) == 3 && parts[0] == "connected-realm" && parts[2] == "auctions":
+		s.serveDynamic(w, r, func(r *http.Request) (interface{}, bool) {
+			return s.auctions(r, parts[1])
+		})
+	case len(parts) == 2 && parts[0] == "auctions" && parts[1] == "commodities":
+		s.serveDynamic(w, r, func(r *http.Request) (interface{}, bool) {
+			return map[string]interface{}{
+				"auctions": auctionsJSON(s.fixtures.Commodities),
+			}, true
+		})
+	case len(parts) == 2 && parts[0] == "item":
+		s.serveStatic(w, r, func(r *http.Request) (interface{}, bool) {
+			return s.item(r, parts[1])
+		})
+	case len(parts) == 2 && parts[0] == "search" && parts[1] == "item":
+		s.serveStatic(w, r, s.itemSearch)
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+// serveToken implements the oauth2 client credentials flow.
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed)
+		return
+	}
+	id, secret, ok := r.BasicAuth()
+	s.mu.Lock()
+	want, known := s.clients[id]
+	s.mu.Unlock()
+	if !ok || !known || secret != want || r.FormValue("grant_type") != "client_credentials" {
+		writeError(w, http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	token := fmt.Sprintf("wowapitest-token-%v", len(s.tokens)+1)
+	s.tokens[token] = id
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   int(_tokenLifetime.Seconds()),
+	})
+}
+
+// clientOf returns the client which issued the request's token, or "" if it has no valid token.
+func (s *Server) clientOf(r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[token]
+}
+
+func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request, body func(*http.Request) (interface{}, bool)) {
+	s.serveNamespace(w, r, wowapiclient.NamespaceStatic, body)
+}
+
+func (s *Server) serveDynamic(w http.ResponseWriter, r *http.Request, body func(*http.Request) (interface{}, bool)) {
+	s.serveNamespace(w, r, wowapiclient.NamespaceDynamic, body)
+}
+
+// serveNamespace responds 404 Not Found, like the real API, unless the request is in a flavor of
+// namespace in the fixture region.
+func (s *Server) serveNamespace(w http.ResponseWriter, r *http.Request, namespace wowapiclient.Namespace, body func(*http.Request) (interface{}, bool)) {
+	ns := requestNamespace(r)
+	if !strings.HasPrefix(ns, string(namespace)) || !strings.HasSuffix(ns, "-"+s.fixtures.Region) {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	b, ok := body(r)
+	if !ok {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, b)
+}
+
+func (s *Server) connectedRealmIndex(r *http.Request) (interface{}, bool) {
+	links := make([]map[string]string, 0, len(s.fixtures.ConnectedRealms))
+	for _, cr := range s.fixtures.ConnectedRealms {
+		links = append(links, s.link(r, fmt.Sprintf("/data/wow/connected-realm/%v", cr.ID)))
+	}
+	return map[string]interface{}{
+		"connected_realms": links,
+	}, true
+}
+
+func (s *Server) connectedRealm(r *http.Request, idStr string) (interface{}, bool) {
+	cr, ok := s.connectedRealms[atoi(idStr)]
+	if !ok {
+		return nil, false
+	}
+
+	realms := make([]map[string]interface{}, 0, len(cr.Realms))
+	for _, realm := range cr.Realms {
+		realms = append(realms, map[string]interface{}{
+			"id":              realm.ID,
+			"name":            localized(r, realm.Name),
+			"slug":            realm.Slug,
+			"category":        localized(r, realm.Category),
+			"locale":          realm.Locale,
+			"timezone":        realm.Timezone,
+			"type":            typedName(string(realm.Type)),
+			"is_tournament":   realm.IsTournament,
+			"connected_realm": s.link(r, fmt.Sprintf("/data/wow/connected-realm/%v", cr.ID)),
+		})
+	}
+	return map[string]interface{}{
+		"_links":     map[string]interface{}{"self": s.link(r, r.URL.Path)},
+		"id":         cr.ID,
+		"has_queue":  cr.HasQueue,
+		"status":     typedName(string(cr.Status)),
+		"population": typedName(string(cr.Population)),
+		"realms":     realms,
+	}, true
+}
+
+func (s *Server) auctions(r *http.Request, idStr string) (interface{}, bool) {
+	id := atoi(idStr)
+	if _, ok := s.connectedRealms[id]; !ok {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"connected_realm": s.link(r, fmt.Sprintf("/data/wow/connected-realm/%v", id)),
+		"auctions":        auctionsJSON(s.fixtures.Auctions[id]),
+	}, true
+}
+
+func (s *Server) item(r *http.Request, idStr string) (interface{}, bool) {
+	i, ok := s.items[atoi(idStr)]
+	if !ok {
+		return nil, false
+	}
+	return s.itemJSON(r, i, localized(r, i.Name)), true
+}
+
+// itemSearch serves pages of the items, ordered by ID, whose name contains the value of every
+// name.<locale> filter. Other filters and orderby are ignored.
+func (s *Server) itemSearch(r *http.Request) (interface{}, bool) {
+	q := r.URL.Query()
+	var matches []wowapiclient.ItemDetail
+	for _, i := range s.fixtures.Items {
+		if matchesNameFilters(q, i.Name) {
+			matches = append(matches, i)
+		}
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		return matches[a].ID < matches[b].ID
+	})
+
+	page, pageSize := atoi(q.Get("_page")), atoi(q.Get("_pageSize"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = _defaultSearchPageSize
+	}
+	if pageSize > _maxSearchPageSize {
+		pageSize = _maxSearchPageSize
+	}
+
+	results := make([]map[string]interface{}, 0, pageSize)
+	for n := (page - 1) * pageSize; n < len(matches) && len(results) < pageSize; n++ {
+		i := matches[n]
+		results = append(results, map[string]interface{}{
+			"key": s.link(r, fmt.Sprintf("/data/wow/item/%v", i.ID)),
+			// search results always have every locale
+			"data": s.itemJSON(r, i, i.Name),
+		})
+	}
+
+	return map[string]interface{}{
+		"page":        page,
+		"pageSize":    pageSize,
+		"maxPageSize": _maxSearchPageSize,
+		"pageCount":   (len(matches) + pageSize - 1) / pageSize,
+		"results":     results,
+	}, true
+}
+
+func matchesNameFilters(q url.Values, name wowapiclient.LocalizedString) bool {
+	for field := range q {
+		if !strings.HasPrefix(field, "name.") {
+			continue
+		}
+		l := wowapiclient.Locale(strings.TrimPrefix(field, "name."))
+		if !strings.Contains(strings.ToLower(name.Get(l)), strings.ToLower(q.Get(field))) {
+			return false
+		}
+	}
+	return true
+}
+
+// itemJSON is the API representation of i with the given name, which is either a string or every
+// locale.
+func (s *Server) itemJSON(r *http.Request, i wowapiclient.ItemDetail, name interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   i.ID,
+		"name": name,
+		"item_class": map[string]interface{}{
+			"key":  s.link(r, fmt.Sprintf("/data/wow/item-class/%v", i.ItemClassID)),
+			"name": i.ItemClass,
+			"id":   i.ItemClassID,
+		},
+		"item_subclass": map[string]interface{}{
+			"key":  s.link(r, fmt.Sprintf("/data/wow/item-class/%v/item-subclass/%v", i.ItemClassID, i.ItemSubclassID)),
+			"name": i.ItemSubclass,
+			"id":   i.ItemSubclassID,
+		},
+		"quality":           typedName(string(i.Quality)),
+		"level":             i.Level,
+		"required_level":    i.RequiredLevel,
+		"inventory_type":    typedName(i.InventoryType),
+		"purchase_price":    i.PurchasePrice,
+		"purchase_quantity": i.PurchaseQuantity,
+		"sell_price":        i.SellPrice,
+		"max_count":         i.MaxCount,
+		"is_equippable":     i.IsEquippable,
+		"is_stackable":      i.IsStackable,
+		"preview_item": map[string]interface{}{
+			"binding": typedName(string(i.Binding)),
+		},
+	}
+}
+
+// link links to path in the namespace of r.
+func (s *Server) link(r *http.Request, path string) map[string]string {
+	return map[string]string{
+		"href": fmt.Sprintf("%s%s?namespace=%s", s.URL, path, url.QueryEscape(requestNamespace(r))),
+	}
+}
+
+func auctionsJSON(auctions []wowapiclient.Auction) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(auctions))
+	for _, a := range auctions {
+		auction := map[string]interface{}{
+			"id":        a.ID,
+			"item":      map[string]int{"id": a.ItemID},
+			"quantity":  a.Quantity,
+			"time_left": a.TimeLeft,
+		}
+		if a.UnitPrice != 0 {
+			auction["unit_price"] = a.UnitPrice
+		}
+		if a.Buyout != 0 {
+			auction["buyout"] = a.Buyout
+		}
+		if a.Bid != 0 {
+			auction["bid"] = a.Bid
+		}
+		out = append(out, auction)
+	}
+	return out
+}
+
+// localized returns the string in the requested locale, or every locale when none is requested.
+func localized(r *http.Request, s wowapiclient.LocalizedString) interface{} {
+	l := r.URL.Query().Get("locale")
+	if l == "" {
+		return s
+	}
+	if v := s.Get(wowapiclient.Locale(l)); v != "" {
+		return v
+	}
+	return s.String()
+}
+
+func typedName(t string) map[string]string {
+	return map[string]string{
+		"type": t,
+		"name": t,
+	}
+}
+
+// requestNamespace gets the namespace from the query string, which takes precedence over the header.
+func requestNamespace(r *http.Request) string {
+	if ns := r.URL.Query().Get("namespace"); ns != "" {
+		return ns
+	}
+	return r.Header.Get("Battlenet-Namespace")
+}
+
+func atoi(s string) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return -1
+	}
+	return i
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":   statusCode,
+		"type":   "BLZWEBAPI00000" + strconv.Itoa(statusCode),
+		"detail": http.StatusText(statusCode),
+	})
+}
+
+// redirectTransport sends every request to target.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}